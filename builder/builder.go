@@ -1,69 +1,349 @@
 package builder
 
-import "bytes"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+	ErrUnsafeExpression  = errors.New("expression contains statement separator or comment")
+	ErrNilQuery          = errors.New("query is nil")
+	ErrNilExpr           = errors.New("condition is nil")
+	ErrInvalidJoin       = errors.New("invalid join type")
+	ErrInvalidOrder      = errors.New("invalid sort direction")
+	ErrInvalidSetOp      = errors.New("invalid set operator")
+	ErrInvalidOffset     = errors.New("offset must not be negative")
+	ErrInvalidLimit      = errors.New("limit must not be negative")
+	ErrInvalidOperator   = errors.New("invalid comparison operator")
+)
 
 // SQL 查询表达式接口, 这是表示过程
+// ToSQL() 中的值均以占位符表示, 对应的绑定参数按顺序由 Args() 返回, 可直接用于 db.Query(q.ToSQL(), q.Args()...)
+// ToSQL()/Args() 使用建造时选择的方言, Render() 则按指定的方言渲染同一个查询
 type ISQLQuery interface {
 	ToSQL() string
+	Args() []interface{}
+	Render(dialect IDialect) (string, []interface{})
 }
 
 // SQL 查询表达式的建造者接口，这是创建过程
 type ISQLQueryBuilder interface {
 	WithTable(table string) ISQLQueryBuilder
+	// 以子查询作为数据源, 即 FROM (query) alias
+	WithTableQuery(query ISQLQuery, alias string) ISQLQueryBuilder
+	// 公共表表达式, 即 WITH name AS (query)
+	WithCTE(name string, query ISQLQuery) ISQLQueryBuilder
 	WithField(field string) ISQLQueryBuilder
 	WithCondition(condition string) ISQLQueryBuilder
+	Where(condition string, args ...interface{}) ISQLQueryBuilder
+	WhereExpr(expr IExpr) ISQLQueryBuilder
+	WithJoin(table string, on string, args ...interface{}) ISQLQueryBuilder
+	WithLeftJoin(table string, on string, args ...interface{}) ISQLQueryBuilder
+	WithRightJoin(table string, on string, args ...interface{}) ISQLQueryBuilder
+	WithJoinExpr(joinType JoinType, table string, on IExpr) ISQLQueryBuilder
+	WithGroupBy(field string) ISQLQueryBuilder
+	WithHaving(condition string, args ...interface{}) ISQLQueryBuilder
+	WithHavingExpr(expr IExpr) ISQLQueryBuilder
 	WithOrderBy(orderBy string) ISQLQueryBuilder
-	Build() ISQLQuery
+	WithOrder(field string, direction SortDirection) ISQLQueryBuilder
+	WithUnion(query ISQLQuery) ISQLQueryBuilder
+	WithUnionAll(query ISQLQuery) ISQLQueryBuilder
+	WithSetOperation(op SetOperator, query ISQLQuery) ISQLQueryBuilder
+	WithLimit(limit int) ISQLQueryBuilder
+	WithOffset(offset int) ISQLQueryBuilder
+	WithDialect(dialect IDialect) ISQLQueryBuilder
+	// 校验并返回当前状态的快照, 之后对建造者的修改不会影响已构建的查询, 因此建造者可以作为模板反复使用
+	// 未指定字段时默认为 SELECT *
+	Build() (error, ISQLQuery)
+}
+
+// 连接类型
+type JoinType string
+
+const (
+	InnerJoin JoinType = "JOIN"
+	LeftJoin  JoinType = "LEFT JOIN"
+	RightJoin JoinType = "RIGHT JOIN"
+)
+
+type sqlJoin struct {
+	joinType JoinType
+	table    string
+	on       IExpr
+}
+
+// 排序方向
+type SortDirection string
+
+const (
+	Asc  SortDirection = "ASC"
+	Desc SortDirection = "DESC"
+)
+
+// 排序项, direction 为空时 field 原样输出
+type sqlOrder struct {
+	field     string
+	direction SortDirection
+}
+
+// 集合运算
+type SetOperator string
+
+const (
+	Union     SetOperator = "UNION"
+	UnionAll  SetOperator = "UNION ALL"
+	Intersect SetOperator = "INTERSECT"
+	Except    SetOperator = "EXCEPT"
+)
+
+type sqlSetOperation struct {
+	op    SetOperator
+	query ISQLQuery
+}
+
+// 公共表表达式
+type sqlCTE struct {
+	name  string
+	query ISQLQuery
+}
+
+// 渲染 SQL 的缓冲区, 按方言输出标识符与占位符, 同时按出现顺序收集绑定参数
+type sqlWriter struct {
+	bytes.Buffer
+	dialect IDialect
+	args    []interface{}
+}
+
+func newSQLWriter(dialect IDialect) *sqlWriter {
+	if dialect == nil {
+		dialect = GenericDialect
+	}
+	return &sqlWriter{
+		dialect: dialect,
+	}
 }
 
 // 实现 ISQLQuery 接口
 type SQLQuery struct {
+	ctes       []sqlCTE
 	table      string
+	tableQuery ISQLQuery // 不为 nil 时以子查询作为数据源, table 为其别名
 	fields     []string
-	conditions []string
-	orderBy    string
+	joins      []sqlJoin
+	conditions []IExpr
+	groupBy    []string
+	having     []IExpr
+	setOps     []sqlSetOperation
+	orderBy    []sqlOrder
+	limit      int  // < 0 表示不限制
+	limitSet   bool // 是否调用过 WithLimit, 显式指定的 limit 不能为负数
+	offset     int
+	dialect    IDialect
 }
 
 func newSQLQuery() *SQLQuery {
 	return &SQLQuery{
+		ctes:       make([]sqlCTE, 0),
 		table:      "",
 		fields:     make([]string, 0),
-		conditions: make([]string, 0),
-		orderBy:    "",
+		joins:      make([]sqlJoin, 0),
+		conditions: make([]IExpr, 0),
+		groupBy:    make([]string, 0),
+		having:     make([]IExpr, 0),
+		setOps:     make([]sqlSetOperation, 0),
+		orderBy:    make([]sqlOrder, 0),
+		limit:      -1,
+		offset:     0,
+		dialect:    GenericDialect,
 	}
 }
 
 func (s *SQLQuery) ToSQL() string {
-	b := bytes.Buffer{}
+	sql, _ := s.Render(s.dialect)
+	return sql
+}
 
-	b.WriteString("SELECT ")
+func (s *SQLQuery) Args() []interface{} {
+	_, args := s.Render(s.dialect)
+	return args
+}
 
-	for i, it := range s.fields {
-		if i > 0 {
-			b.WriteString(",")
+func (s *SQLQuery) Render(dialect IDialect) (string, []interface{}) {
+	b := newSQLWriter(dialect)
+	s.writeTo(b)
+	return b.String(), b.args
+}
+
+// ORDER BY/LIMIT/OFFSET 作用于整个集合运算的结果, 带有这些子句的查询作为集合运算成员时需要加括号
+func (s *SQLQuery) hasTail() bool {
+	return len(s.orderBy) > 0 || s.limit >= 0 || s.offset > 0
+}
+
+func (s *SQLQuery) writeTo(b *sqlWriter) {
+	if len(s.ctes) > 0 {
+		b.WriteString("WITH ")
+		for i, it := range s.ctes {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.writeIdentifier(it.name)
+			b.WriteString(" AS (")
+			b.writeQuery(it.query)
+			b.WriteString(")")
 		}
-		b.WriteString(it)
+		b.WriteString(" ")
 	}
 
+	b.WriteString("SELECT ")
+	b.writeIdentifiers(s.fields)
+
 	b.WriteString(" FROM ")
-	b.WriteString(s.table)
+	if s.tableQuery != nil {
+		b.WriteString("(")
+		b.writeQuery(s.tableQuery)
+		b.WriteString(") ")
+	}
+	b.writeIdentifier(s.table)
+
+	for _, it := range s.joins {
+		b.WriteString(" ")
+		b.WriteString(string(it.joinType))
+		b.WriteString(" ")
+		b.writeIdentifier(it.table)
+		b.WriteString(" ON ")
+		it.on.writeTo(b)
+	}
 
 	if len(s.conditions) > 0 {
 		b.WriteString(" WHERE ")
-		for i, it := range s.conditions {
-			if i > 0 {
-				b.WriteString(" AND ")
-			}
-			b.WriteString(it)
+		b.writeConditions(s.conditions)
+	}
+
+	if len(s.groupBy) > 0 {
+		b.WriteString(" GROUP BY ")
+		b.writeIdentifiers(s.groupBy)
+	}
+
+	if len(s.having) > 0 {
+		b.WriteString(" HAVING ")
+		b.writeConditions(s.having)
+	}
+
+	for _, it := range s.setOps {
+		b.WriteString(" ")
+		b.WriteString(string(it.op))
+		b.WriteString(" ")
+		if member, ok := it.query.(*SQLQuery); ok && member.hasTail() {
+			b.WriteString("(")
+			b.writeQuery(it.query)
+			b.WriteString(")")
+		} else {
+			b.writeQuery(it.query)
 		}
 	}
 
 	if len(s.orderBy) > 0 {
 		b.WriteString(" ORDER BY ")
-		b.WriteString(s.orderBy)
+		for i, it := range s.orderBy {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			if len(it.direction) > 0 {
+				b.writeIdentifier(it.field)
+				b.WriteString(" ")
+				b.WriteString(string(it.direction))
+			} else {
+				b.WriteString(it.field)
+			}
+		}
 	}
 
-	return b.String()
+	if limit := b.dialect.Limit(s.limit, s.offset); len(limit) > 0 {
+		b.WriteString(" ")
+		b.WriteString(limit)
+	}
+}
+
+func (b *sqlWriter) writeIdentifier(name string) {
+	b.WriteString(quoteIdentifier(b.dialect, name))
+}
+
+func (b *sqlWriter) writeIdentifiers(items []string) {
+	for i, it := range items {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.writeIdentifier(it)
+	}
+}
+
+// 输出一个绑定参数的占位符, BoolLiteral 直接输出为方言的布尔字面量, ISQLQuery 输出为括号内的子查询
+func (b *sqlWriter) writeArg(arg interface{}) {
+	switch it := arg.(type) {
+	case BoolLiteral:
+		b.WriteString(b.dialect.Bool(bool(it)))
+		return
+	case ISQLQuery:
+		b.WriteString("(")
+		b.writeQuery(it)
+		b.WriteString(")")
+		return
+	}
+	b.args = append(b.args, arg)
+	b.WriteString(b.dialect.Placeholder(len(b.args)))
+}
+
+// 统计 SQL 片段中引号之外的 ? 占位符个数, 与 writeRaw 的替换规则一致
+func countPlaceholders(sql string) int {
+	n := 0
+	var quote rune
+	for _, c := range sql {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+		}
+	}
+	return n
+}
+
+// 输出 SQL 片段, 并将引号之外的每个 ? 依次替换为方言的占位符
+func (b *sqlWriter) writeRaw(sql string, args []interface{}) {
+	next := 0
+	var quote rune
+	for _, c := range sql {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?' && next < len(args):
+			b.writeArg(args[next])
+			next++
+			continue
+		}
+		b.WriteRune(c)
+	}
+
+	// 多出的参数仍按顺序追加, 由数据库报告参数个数不匹配
+	for ; next < len(args); next++ {
+		b.args = append(b.args, args[next])
+	}
+}
+
+// 多个条件以 AND 连接
+func (b *sqlWriter) writeConditions(items []IExpr) {
+	b.writeJunction(items, " AND ", precedenceAnd, true)
 }
 
 type SQLQueryBuilder struct {
@@ -81,21 +361,375 @@ func (s *SQLQueryBuilder) WithTable(table string) ISQLQueryBuilder {
 	return s
 }
 
+func (s *SQLQueryBuilder) WithTableQuery(query ISQLQuery, alias string) ISQLQueryBuilder {
+	s.query.tableQuery = query
+	s.query.table = alias
+	return s
+}
+
+func (s *SQLQueryBuilder) WithCTE(name string, query ISQLQuery) ISQLQueryBuilder {
+	s.query.ctes = append(s.query.ctes, sqlCTE{
+		name:  name,
+		query: query,
+	})
+	return s
+}
+
 func (s *SQLQueryBuilder) WithField(field string) ISQLQueryBuilder {
 	s.query.fields = append(s.query.fields, field)
 	return s
 }
 
 func (s *SQLQueryBuilder) WithCondition(condition string) ISQLQueryBuilder {
-	s.query.conditions = append(s.query.conditions, condition)
+	return s.Where(condition)
+}
+
+// 参数化条件, 例如 Where("age > ?", 18), 值不会被拼接到 SQL 中
+func (s *SQLQueryBuilder) Where(condition string, args ...interface{}) ISQLQueryBuilder {
+	return s.WhereExpr(Raw(condition, args...))
+}
+
+// 以表达式树作为条件, 例如 WhereExpr(Or(Eq("city", "广州"), Gt("age", 18)))
+func (s *SQLQueryBuilder) WhereExpr(expr IExpr) ISQLQueryBuilder {
+	s.query.conditions = append(s.query.conditions, expr)
 	return s
 }
 
+func (s *SQLQueryBuilder) WithJoin(table string, on string, args ...interface{}) ISQLQueryBuilder {
+	return s.WithJoinExpr(InnerJoin, table, Raw(on, args...))
+}
+
+func (s *SQLQueryBuilder) WithLeftJoin(table string, on string, args ...interface{}) ISQLQueryBuilder {
+	return s.WithJoinExpr(LeftJoin, table, Raw(on, args...))
+}
+
+func (s *SQLQueryBuilder) WithRightJoin(table string, on string, args ...interface{}) ISQLQueryBuilder {
+	return s.WithJoinExpr(RightJoin, table, Raw(on, args...))
+}
+
+func (s *SQLQueryBuilder) WithJoinExpr(joinType JoinType, table string, on IExpr) ISQLQueryBuilder {
+	s.query.joins = append(s.query.joins, sqlJoin{
+		joinType: joinType,
+		table:    table,
+		on:       on,
+	})
+	return s
+}
+
+func (s *SQLQueryBuilder) WithGroupBy(field string) ISQLQueryBuilder {
+	s.query.groupBy = append(s.query.groupBy, field)
+	return s
+}
+
+func (s *SQLQueryBuilder) WithHaving(condition string, args ...interface{}) ISQLQueryBuilder {
+	return s.WithHavingExpr(Raw(condition, args...))
+}
+
+func (s *SQLQueryBuilder) WithHavingExpr(expr IExpr) ISQLQueryBuilder {
+	s.query.having = append(s.query.having, expr)
+	return s
+}
+
+// 追加一个原样输出的排序项, 例如 WithOrderBy("price desc")
 func (s *SQLQueryBuilder) WithOrderBy(orderBy string) ISQLQueryBuilder {
-	s.query.orderBy = orderBy
+	s.query.orderBy = append(s.query.orderBy, sqlOrder{
+		field: orderBy,
+	})
+	return s
+}
+
+func (s *SQLQueryBuilder) WithOrder(field string, direction SortDirection) ISQLQueryBuilder {
+	s.query.orderBy = append(s.query.orderBy, sqlOrder{
+		field:     field,
+		direction: direction,
+	})
 	return s
 }
 
-func (s *SQLQueryBuilder) Build() ISQLQuery {
-	return s.query
+func (s *SQLQueryBuilder) WithUnion(query ISQLQuery) ISQLQueryBuilder {
+	return s.WithSetOperation(Union, query)
+}
+
+func (s *SQLQueryBuilder) WithUnionAll(query ISQLQuery) ISQLQueryBuilder {
+	return s.WithSetOperation(UnionAll, query)
+}
+
+func (s *SQLQueryBuilder) WithSetOperation(op SetOperator, query ISQLQuery) ISQLQueryBuilder {
+	s.query.setOps = append(s.query.setOps, sqlSetOperation{
+		op:    op,
+		query: query,
+	})
+	return s
+}
+
+func (s *SQLQueryBuilder) WithLimit(limit int) ISQLQueryBuilder {
+	s.query.limit = limit
+	s.query.limitSet = true
+	return s
+}
+
+func (s *SQLQueryBuilder) WithOffset(offset int) ISQLQueryBuilder {
+	s.query.offset = offset
+	return s
+}
+
+func (s *SQLQueryBuilder) WithDialect(dialect IDialect) ISQLQueryBuilder {
+	s.query.dialect = dialect
+	return s
+}
+
+func (s *SQLQueryBuilder) Build() (error, ISQLQuery) {
+	if err := s.query.validate(); err != nil {
+		return err, nil
+	}
+
+	query := s.query.clone()
+	if len(query.fields) == 0 {
+		query.fields = append(query.fields, "*")
+	}
+	return nil, query
+}
+
+func (s *SQLQuery) clone() *SQLQuery {
+	query := *s
+	query.ctes = append([]sqlCTE{}, s.ctes...)
+	query.fields = append([]string{}, s.fields...)
+	query.joins = append([]sqlJoin{}, s.joins...)
+	for i, it := range query.joins {
+		query.joins[i].on = cloneExpr(it.on)
+	}
+	query.conditions = cloneExprs(s.conditions)
+	query.groupBy = append([]string{}, s.groupBy...)
+	query.having = cloneExprs(s.having)
+	query.setOps = append([]sqlSetOperation{}, s.setOps...)
+	query.orderBy = append([]sqlOrder{}, s.orderBy...)
+	return &query
+}
+
+func (s *SQLQuery) validate() error {
+	for _, it := range s.ctes {
+		if !identPattern.MatchString(it.name) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, it.name)
+		}
+		if it.query == nil {
+			return fmt.Errorf("%w: WITH %s", ErrNilQuery, it.name)
+		}
+	}
+
+	for _, it := range s.fields {
+		if err := validateExpression(it); err != nil {
+			return err
+		}
+	}
+
+	if s.tableQuery != nil {
+		if !identPattern.MatchString(s.table) {
+			return fmt.Errorf("%w: subquery alias %q", ErrInvalidIdentifier, s.table)
+		}
+	} else {
+		if len(s.table) == 0 {
+			return ErrEmptyTable
+		}
+		if !isTableName(s.table) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, s.table)
+		}
+	}
+
+	for _, it := range s.joins {
+		switch it.joinType {
+		case InnerJoin, LeftJoin, RightJoin:
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidJoin, it.joinType)
+		}
+		if !isTableName(it.table) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, it.table)
+		}
+		if err := validateExpr(it.on); err != nil {
+			return fmt.Errorf("JOIN %s ON: %w", it.table, err)
+		}
+	}
+
+	for _, it := range append(append([]IExpr{}, s.conditions...), s.having...) {
+		if err := validateExpr(it); err != nil {
+			return err
+		}
+	}
+
+	for _, it := range s.groupBy {
+		if err := validateExpression(it); err != nil {
+			return err
+		}
+	}
+
+	for _, it := range s.setOps {
+		switch it.op {
+		case Union, UnionAll, Intersect, Except:
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidSetOp, it.op)
+		}
+		if it.query == nil {
+			return fmt.Errorf("%w: %s", ErrNilQuery, it.op)
+		}
+	}
+
+	for _, it := range s.orderBy {
+		switch it.direction {
+		case "":
+			if err := validateExpression(it.field); err != nil {
+				return err
+			}
+		case Asc, Desc:
+			if _, ok := quotePath(GenericDialect, it.field); !ok || strings.HasSuffix(it.field, "*") {
+				return fmt.Errorf("%w: %q", ErrInvalidIdentifier, it.field)
+			}
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidOrder, it.direction)
+		}
+	}
+
+	if s.limitSet && s.limit < 0 {
+		return ErrInvalidLimit
+	}
+	if s.offset < 0 {
+		return ErrInvalidOffset
+	}
+	return nil
+}
+
+// 表名为 a.b 形式的路径, 可以带 "name alias"/"name AS alias" 形式的别名
+func isTableName(name string) bool {
+	parts := strings.Fields(name)
+	switch {
+	case len(parts) == 2:
+		if !identPattern.MatchString(parts[1]) {
+			return false
+		}
+	case len(parts) == 3:
+		if !strings.EqualFold(parts[1], "AS") || !identPattern.MatchString(parts[2]) {
+			return false
+		}
+	case len(parts) != 1:
+		return false
+	}
+
+	_, ok := quotePath(GenericDialect, parts[0])
+	return ok && !strings.HasSuffix(parts[0], "*")
+}
+
+// 字段、分组与排序项可以是表达式, 但不允许为空或包含语句分隔符与注释
+func validateExpression(expr string) error {
+	if len(strings.TrimSpace(expr)) == 0 {
+		return fmt.Errorf("%w: empty expression", ErrInvalidIdentifier)
+	}
+	if strings.Contains(expr, ";") || strings.Contains(expr, "--") || strings.Contains(expr, "/*") {
+		return fmt.Errorf("%w: %q", ErrUnsafeExpression, expr)
+	}
+	return nil
+}
+
+var compareOps = map[string]bool{"=": true, "<>": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// 校验整棵表达式树: 任何一层的子表达式与 EXISTS 子查询都不能为 nil, 以免渲染时 panic
+// 列与比较运算符会原样拼入 SQL, 不能包含任意的 SQL 片段; SQL 片段的占位符个数必须与参数个数一致
+func validateExpr(expr IExpr) error {
+	if expr == nil {
+		return ErrNilExpr
+	}
+
+	var err error
+	Walk(expr, func(expr IExpr) bool {
+		if err != nil {
+			return false
+		}
+		switch it := expr.(type) {
+		case *AndExpr:
+			err = validateItems("AND", it.Items)
+		case *OrExpr:
+			err = validateItems("OR", it.Items)
+		case *NotExpr:
+			err = validateItems("NOT", []IExpr{it.Item})
+		case *ExistsExpr:
+			if it.Query == nil {
+				err = fmt.Errorf("%w: EXISTS", ErrNilQuery)
+			}
+		case *RawExpr:
+			// 占位符与参数个数不一致时只有部分 ? 会被替换为方言的占位符
+			if n := countPlaceholders(it.SQL); n != len(it.Args) {
+				err = fmt.Errorf("%w: %q has %d placeholders, got %d args", ErrArgCount, it.SQL, n, len(it.Args))
+			}
+		case *CompareExpr:
+			if !compareOps[it.Op] {
+				err = fmt.Errorf("%w: %q", ErrInvalidOperator, it.Op)
+			} else {
+				err = validateColumn(it.Column)
+			}
+		case *InExpr:
+			err = validateColumn(it.Column)
+		case *BetweenExpr:
+			err = validateColumn(it.Column)
+		case *LikeExpr:
+			err = validateColumn(it.Column)
+		case *IsNullExpr:
+			err = validateColumn(it.Column)
+		}
+		return err == nil
+	})
+	return err
+}
+
+func validateItems(op string, items []IExpr) error {
+	for _, it := range items {
+		if it == nil {
+			return fmt.Errorf("%w: %s", ErrNilExpr, op)
+		}
+	}
+	return nil
+}
+
+// 表达式中的列为 a.b 形式的路径或 name(...) 形式的函数调用, 例如 count(*)
+func validateColumn(column string) error {
+	if _, ok := quotePath(GenericDialect, column); ok && !strings.HasSuffix(column, "*") {
+		return nil
+	}
+	if err := validateExpression(column); err != nil {
+		return err
+	}
+	if !isFunctionCall(column) {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, column)
+	}
+	return nil
+}
+
+// 函数名之后的括号必须在末尾闭合, 引号之外不允许出现占位符
+func isFunctionCall(expr string) bool {
+	open := strings.Index(expr, "(")
+	if open <= 0 {
+		return false
+	}
+	if _, ok := quotePath(GenericDialect, strings.TrimSpace(expr[:open])); !ok {
+		return false
+	}
+
+	depth := 0
+	var quote rune
+	for i, c := range expr[open:] {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			return false
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return open+i == len(expr)-1
+			}
+		}
+	}
+	return false
 }
@@ -1,8 +1,14 @@
 package builder
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
 )
 
 func Test_Builder(t *testing.T) {
@@ -11,6 +17,249 @@ func Test_Builder(t *testing.T) {
 		WithField("id").WithField("name").WithField("price").
 		WithCondition("enable=1").
 		WithOrderBy("price desc")
-	query := builder.Build()
+	query := mustBuild(t, builder)
 	fmt.Println(query.ToSQL())
 }
+
+func Test_BuilderArgs(t *testing.T) {
+	name := "Robert'); DROP TABLE students;--"
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("student").
+		WithField("id").WithField("name").
+		WithCondition("enable=1").
+		Where("age > ?", 18).
+		Where("name = ? OR nickname = ?", name, name))
+
+	expected := "SELECT id,name FROM student WHERE (enable=1) AND (age > ?) AND (name = ? OR nickname = ?)"
+	if query.ToSQL() != expected {
+		t.Fatalf("expecting %s, got %s", expected, query.ToSQL())
+	}
+	if strings.Contains(query.ToSQL(), name) || strings.Contains(query.ToSQL(), "18") {
+		t.Fatalf("expecting values not to appear inline, got %s", query.ToSQL())
+	}
+	if !reflect.DeepEqual(query.Args(), []interface{}{18, name, name}) {
+		t.Fatalf("expecting args [18 %s %s], got %v", name, name, query.Args())
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("mock error: '%s'", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	mock.ExpectQuery(regexp.QuoteMeta(expected)).
+		WithArgs(18, name, name).
+		WillReturnRows(mock.NewRows([]string{"id", "name"}).AddRow(1, "Robert"))
+	rows, err := db.Query(query.ToSQL(), query.Args()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 多个条件以 AND 连接时, 每个原样输出的条件都加括号, 条件内的 OR 不会扩散到整个 WHERE/HAVING
+func Test_BuilderConditionGrouping(t *testing.T) {
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("student").
+		WithField("city").WithField("count(*) total").
+		Where("name = ? OR nickname = ?", "bob", "bob").
+		WhereExpr(Or(Eq("grade", 1), Eq("grade", 2))).
+		Where("enable = 1").
+		WithGroupBy("city").
+		WithHaving("count(*) > ? OR max(age) > ?", 10, 20).
+		WithHaving("min(age) > ?", 6))
+
+	expected := "SELECT city,count(*) total FROM student" +
+		" WHERE (name = ? OR nickname = ?) AND (grade = ? OR grade = ?) AND (enable = 1)" +
+		" GROUP BY city" +
+		" HAVING (count(*) > ? OR max(age) > ?) AND (min(age) > ?)"
+	if query.ToSQL() != expected {
+		t.Fatalf("expecting %s, got %s", expected, query.ToSQL())
+	}
+
+	// 只有一个条件时不需要括号
+	query = mustBuild(t, newSQLQueryBuilder().WithTable("student").Where("name = ? OR nickname = ?", "bob", "bob"))
+	if expected := "SELECT * FROM student WHERE name = ? OR nickname = ?"; query.ToSQL() != expected {
+		t.Fatalf("expecting %s, got %s", expected, query.ToSQL())
+	}
+}
+
+func Test_BuilderClauses(t *testing.T) {
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("orders o").
+		WithField("c.name").WithField("sum(o.amount) total").
+		WithJoin("customer c", "c.id = o.customer_id").
+		WithLeftJoin("coupon p", "p.id = o.coupon_id AND p.kind = ?", "discount").
+		WithRightJoin("region r", "r.id = c.region_id").
+		Where("o.status = ?", "paid").
+		WithGroupBy("c.name").WithGroupBy("c.id").
+		WithHaving("sum(o.amount) > ?", 100).
+		WithOrder("total", Desc).
+		WithOrder("c.name", Asc).
+		WithOrderBy("c.id desc").
+		WithLimit(10).
+		WithOffset(20))
+
+	expected := "SELECT c.name,sum(o.amount) total FROM orders o" +
+		" JOIN customer c ON c.id = o.customer_id" +
+		" LEFT JOIN coupon p ON p.id = o.coupon_id AND p.kind = ?" +
+		" RIGHT JOIN region r ON r.id = c.region_id" +
+		" WHERE o.status = ?" +
+		" GROUP BY c.name,c.id" +
+		" HAVING sum(o.amount) > ?" +
+		" ORDER BY total DESC,c.name ASC,c.id desc" +
+		" LIMIT 10 OFFSET 20"
+	if query.ToSQL() != expected {
+		t.Fatalf("expecting %s, got %s", expected, query.ToSQL())
+	}
+	if !reflect.DeepEqual(query.Args(), []interface{}{"discount", "paid", 100}) {
+		t.Fatalf("expecting args in clause order, got %v", query.Args())
+	}
+
+	query = mustBuild(t, newSQLQueryBuilder().WithTable("product").WithField("id").WithLimit(0))
+	if query.ToSQL() != "SELECT id FROM product LIMIT 0" {
+		t.Fatalf("expecting LIMIT 0, got %s", query.ToSQL())
+	}
+}
+
+func Test_BuilderValidate(t *testing.T) {
+	query := mustBuild(t, newSQLQueryBuilder().WithTable("product"))
+	if query.ToSQL() != "SELECT * FROM product" {
+		t.Fatalf("expecting default SELECT *, got %s", query.ToSQL())
+	}
+
+	cases := []struct {
+		builder ISQLQueryBuilder
+		err     error
+	}{
+		{newSQLQueryBuilder(), ErrEmptyTable},
+		{newSQLQueryBuilder().WithTable("product; DROP TABLE product"), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("product p x"), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("product").WithField("id -- comment"), ErrUnsafeExpression},
+		{newSQLQueryBuilder().WithTable("product").WithField(" "), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("product").WithJoin("orders o;", "o.pid = product.id"), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("product").WithJoinExpr("CROSS JOIN", "orders", Raw("1 = 1")), ErrInvalidJoin},
+		{newSQLQueryBuilder().WithTable("product").WithJoinExpr(InnerJoin, "orders", nil), ErrNilExpr},
+		{newSQLQueryBuilder().WithTable("product").WhereExpr(nil), ErrNilExpr},
+		{newSQLQueryBuilder().WithTable("product").WithOrder("price", "DOWN"), ErrInvalidOrder},
+		{newSQLQueryBuilder().WithTable("product").WithOrder("sum(price)", Desc), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("product").WithOrderBy("price; DELETE FROM product"), ErrUnsafeExpression},
+		{newSQLQueryBuilder().WithTable("product").WithUnion(nil), ErrNilQuery},
+		{newSQLQueryBuilder().WithTable("product").WithCTE("my cte", query), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTableQuery(query, ""), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("product").WithOffset(-1), ErrInvalidOffset},
+		{newSQLQueryBuilder().WithTable("product").WithLimit(-1), ErrInvalidLimit},
+		{newSQLQueryBuilder().WithTable("t").Where("a = ? AND b = ?", 1), ErrArgCount},
+		{newSQLQueryBuilder().WithTable("t").WhereExpr(Or(Eq("a", 1), Raw("b = ?", 2, 3))), ErrArgCount},
+		{newSQLQueryBuilder().WithTable("t").WithJoin("u", "u.id = t.id AND u.org_id = ?"), ErrArgCount},
+		{newSQLQueryBuilder().WithTable("t").WhereExpr(And(Eq("a", 1), nil)), ErrNilExpr},
+		{newSQLQueryBuilder().WithTable("t").WhereExpr(Or(Eq("a", 1), Not(nil))), ErrNilExpr},
+		{newSQLQueryBuilder().WithTable("t").WhereExpr(Exists(nil)), ErrNilQuery},
+		{newSQLQueryBuilder().WithTable("t").WithGroupBy("a").WithHavingExpr(Not(And(nil))), ErrNilExpr},
+		{newSQLQueryBuilder().WithTable("t").WithJoinExpr(InnerJoin, "u", And(Raw("u.id = t.id"), nil)), ErrNilExpr},
+		{newSQLQueryBuilder().WithTable("t").WhereExpr(Eq("id = 1; DROP TABLE t; --", 1)), ErrUnsafeExpression},
+		{newSQLQueryBuilder().WithTable("t").WhereExpr(Not(In("id = 1 OR 1", 1))), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("t").WhereExpr(&CompareExpr{Column: "id", Op: "= 1 OR 1 =", Value: 1}), ErrInvalidOperator},
+		{newSQLQueryBuilder().WithTable("t").WithGroupBy("id").WithHavingExpr(Gt("count(*) OR f()", 1)), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("t").WithGroupBy("id").WithHavingExpr(Gt("count(?)", 1)), ErrInvalidIdentifier},
+		{newSQLQueryBuilder().WithTable("t").WithJoinExpr(InnerJoin, "u", Or(IsNull("u.id x"))), ErrInvalidIdentifier},
+	}
+	for i, it := range cases {
+		err, query := it.builder.Build()
+		if !errors.Is(err, it.err) || query != nil {
+			t.Fatalf("case %d: expecting %v, got %v", i, it.err, err)
+		}
+	}
+
+	// 列可以是路径或函数调用, 比较运算符 != 也是合法的
+	query = mustBuild(t, newSQLQueryBuilder().WithTable("t").
+		WhereExpr(Ne("lower(t.name)", "tv")).WhereExpr(&CompareExpr{Column: "coalesce(a,')')", Op: "!=", Value: 1}))
+	expectSQL(t, query, GenericDialect, "SELECT * FROM t WHERE lower(t.name) <> ? AND coalesce(a,')') != ?", "tv", 1)
+
+	// 引号内的 ? 不是占位符
+	query = mustBuild(t, newSQLQueryBuilder().WithTable("t").Where("name <> '?' AND id = ?", 1))
+	expectSQL(t, query, PostgreSQLDialect, `SELECT * FROM "t" WHERE name <> '?' AND id = $1`, 1)
+}
+
+func Test_BuilderTemplate(t *testing.T) {
+	template := newSQLQueryBuilder().
+		WithTable("product").WithField("id").
+		Where("enable = ?", 1)
+	all := mustBuild(t, template)
+
+	cheap := mustBuild(t, template.Where("price < ?", 10).WithOrder("price", Asc))
+	if all.ToSQL() != "SELECT id FROM product WHERE enable = ?" || !reflect.DeepEqual(all.Args(), []interface{}{1}) {
+		t.Fatalf("expecting built query not to change with builder, got %s %v", all.ToSQL(), all.Args())
+	}
+	if cheap.ToSQL() != "SELECT id FROM product WHERE (enable = ?) AND (price < ?) ORDER BY price ASC" {
+		t.Fatalf("expecting template conditions to be kept, got %s", cheap.ToSQL())
+	}
+
+	template.WithField("name").WithLimit(1)
+	if cheap.ToSQL() != "SELECT id FROM product WHERE (enable = ?) AND (price < ?) ORDER BY price ASC" {
+		t.Fatalf("expecting built query not to change with builder, got %s", cheap.ToSQL())
+	}
+
+	// 表达式节点被深拷贝, 构建之后修改节点不影响已构建的查询
+	in := &InExpr{Column: "id", Values: []interface{}{1, 2}}
+	eq := &CompareExpr{Column: "city", Op: "=", Value: "广州"}
+	on := &CompareExpr{Column: "o.product_id", Op: "=", Value: 3}
+	raw := &RawExpr{SQL: "price < ?", Args: []interface{}{10}}
+	query := mustBuild(t, newSQLQueryBuilder().WithTable("product").
+		WithJoinExpr(InnerJoin, "orders o", on).
+		WhereExpr(And(in, Not(eq))).WithGroupBy("id").WithHavingExpr(raw))
+	in.Values[0] = 9
+	in.Values = append(in.Values, 3)
+	eq.Column, eq.Value = "name", "tv"
+	on.Op = "<>"
+	raw.Args[0] = 99
+	expectSQL(t, query, GenericDialect,
+		"SELECT * FROM product JOIN orders o ON o.product_id = ? WHERE id IN (?,?) AND NOT city = ? GROUP BY id HAVING price < ?",
+		3, 1, 2, "广州", 10)
+
+	update := newSQLUpdateBuilder().WithTable("product").WithSet("name", "tv").WhereExpr(in)
+	updateQuery := mustBuild(t, update)
+	in.Values[0] = 7
+	expectSQL(t, updateQuery, GenericDialect, "UPDATE product SET name=? WHERE id IN (?,?,?)", "tv", 9, 2, 3)
+}
+
+func mustBuild(t *testing.T, builder interface{ Build() (error, ISQLQuery) }) ISQLQuery {
+	t.Helper()
+	err, query := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query
+}
+
+// 按方言渲染查询或条件表达式, 比较输出的 SQL 与参数
+func expectSQL(t *testing.T, it interface{}, dialect IDialect, sql string, args ...interface{}) {
+	t.Helper()
+	var actual string
+	var actualArgs []interface{}
+	switch it := it.(type) {
+	case ISQLQuery:
+		actual, actualArgs = it.Render(dialect)
+	case IExpr:
+		b := newSQLWriter(dialect)
+		it.writeTo(b)
+		actual, actualArgs = b.String(), b.args
+	default:
+		t.Fatalf("expecting ISQLQuery or IExpr, got %T", it)
+	}
+
+	if actual != sql {
+		t.Fatalf("expecting %s, got %s", sql, actual)
+	}
+	if len(args) == 0 {
+		args = nil
+	}
+	if !reflect.DeepEqual(actualArgs, args) {
+		t.Fatalf("expecting args %v, got %v", args, actualArgs)
+	}
+}
@@ -0,0 +1,200 @@
+package builder
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SQL 方言接口, 决定占位符、标识符引用、分页语法与布尔字面量的写法
+type IDialect interface {
+	Name() string
+	// index 从 1 开始
+	Placeholder(index int) string
+	QuoteIdentifier(name string) string
+	// limit < 0 表示不限制, offset <= 0 表示不跳过, 均不需要时返回空串
+	Limit(limit int, offset int) string
+	Bool(b bool) string
+}
+
+// 以 BoolLiteral 作为绑定参数时, 按方言直接输出布尔字面量而不是占位符
+type BoolLiteral bool
+
+var (
+	GenericDialect    IDialect = &genericDialect{}
+	MySQLDialect      IDialect = &mysqlDialect{}
+	PostgreSQLDialect IDialect = &postgresDialect{}
+	SQLiteDialect     IDialect = &sqliteDialect{}
+)
+
+func limitOffset(limit int, offset int) string {
+	b := strings.Builder{}
+	if limit >= 0 {
+		b.WriteString("LIMIT ")
+		b.WriteString(strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString("OFFSET ")
+		b.WriteString(strconv.Itoa(offset))
+	}
+	return b.String()
+}
+
+func boolKeyword(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// 通用方言, 不引用标识符, 与未指定方言时的输出一致
+type genericDialect struct {
+}
+
+func (g *genericDialect) Name() string {
+	return "generic"
+}
+
+func (g *genericDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (g *genericDialect) QuoteIdentifier(name string) string {
+	return name
+}
+
+func (g *genericDialect) Limit(limit int, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+func (g *genericDialect) Bool(b bool) string {
+	return boolKeyword(b)
+}
+
+type mysqlDialect struct {
+}
+
+func (m *mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (m *mysqlDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (m *mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// MySQL 不支持单独的 OFFSET, 需要给出最大的 LIMIT
+func (m *mysqlDialect) Limit(limit int, offset int) string {
+	if limit < 0 && offset > 0 {
+		return "LIMIT 18446744073709551615 OFFSET " + strconv.Itoa(offset)
+	}
+	return limitOffset(limit, offset)
+}
+
+func (m *mysqlDialect) Bool(b bool) string {
+	return boolKeyword(b)
+}
+
+type postgresDialect struct {
+}
+
+func (p *postgresDialect) Name() string {
+	return "postgresql"
+}
+
+func (p *postgresDialect) Placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+func (p *postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func (p *postgresDialect) Limit(limit int, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+func (p *postgresDialect) Bool(b bool) string {
+	return boolKeyword(b)
+}
+
+type sqliteDialect struct {
+}
+
+func (s *sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (s *sqliteDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (s *sqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// SQLite 不支持单独的 OFFSET, 以 LIMIT -1 表示不限制
+func (s *sqliteDialect) Limit(limit int, offset int) string {
+	if limit < 0 && offset > 0 {
+		return "LIMIT -1 OFFSET " + strconv.Itoa(offset)
+	}
+	return limitOffset(limit, offset)
+}
+
+// SQLite 早期版本不识别 TRUE/FALSE
+func (s *sqliteDialect) Bool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// 按方言引用标识符, 支持 a.b 形式的路径以及 "name alias"/"name AS alias" 形式的别名
+// 不是简单标识符的内容(函数调用、表达式等)原样输出
+func quoteIdentifier(dialect IDialect, name string) string {
+	if quoted, ok := quotePath(dialect, name); ok {
+		return quoted
+	}
+
+	parts := strings.Fields(name)
+	alias := ""
+	switch {
+	case len(parts) == 2 && identPattern.MatchString(parts[1]):
+		alias = parts[1]
+	case len(parts) == 3 && strings.EqualFold(parts[1], "AS") && identPattern.MatchString(parts[2]):
+		alias = parts[2]
+	default:
+		return name
+	}
+
+	quoted, ok := quotePath(dialect, parts[0])
+	if !ok {
+		return name
+	}
+	if len(parts) == 3 {
+		return quoted + " " + parts[1] + " " + dialect.QuoteIdentifier(alias)
+	}
+	return quoted + " " + dialect.QuoteIdentifier(alias)
+}
+
+func quotePath(dialect IDialect, name string) (string, bool) {
+	segments := strings.Split(name, ".")
+	for i, it := range segments {
+		if it == "*" && i == len(segments)-1 {
+			continue
+		}
+		if !identPattern.MatchString(it) {
+			return "", false
+		}
+		segments[i] = dialect.QuoteIdentifier(it)
+	}
+	return strings.Join(segments, "."), true
+}
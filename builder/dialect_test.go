@@ -0,0 +1,86 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Dialect(t *testing.T) {
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("user_info u").
+		WithField("u.id").WithField("u.name AS login").WithField("count(*)").
+		WithLeftJoin("org o", "o.id = u.org_id AND o.name <> '?'").
+		Where("u.age > ? AND u.role = ?", 18, "admin").
+		Where("u.enable = ?", BoolLiteral(true)).
+		WithGroupBy("u.id").
+		WithOrder("u.name", Asc).
+		WithOffset(20))
+
+	cases := []struct {
+		dialect IDialect
+		sql     string
+	}{
+		{
+			GenericDialect,
+			"SELECT u.id,u.name AS login,count(*) FROM user_info u LEFT JOIN org o ON o.id = u.org_id AND o.name <> '?'" +
+				" WHERE (u.age > ? AND u.role = ?) AND (u.enable = TRUE) GROUP BY u.id ORDER BY u.name ASC OFFSET 20",
+		},
+		{
+			MySQLDialect,
+			"SELECT `u`.`id`,`u`.`name` AS `login`,count(*) FROM `user_info` `u` LEFT JOIN `org` `o` ON o.id = u.org_id AND o.name <> '?'" +
+				" WHERE (u.age > ? AND u.role = ?) AND (u.enable = TRUE) GROUP BY `u`.`id` ORDER BY `u`.`name` ASC LIMIT 18446744073709551615 OFFSET 20",
+		},
+		{
+			PostgreSQLDialect,
+			`SELECT "u"."id","u"."name" AS "login",count(*) FROM "user_info" "u" LEFT JOIN "org" "o" ON o.id = u.org_id AND o.name <> '?'` +
+				` WHERE (u.age > $1 AND u.role = $2) AND (u.enable = TRUE) GROUP BY "u"."id" ORDER BY "u"."name" ASC OFFSET 20`,
+		},
+		{
+			SQLiteDialect,
+			`SELECT "u"."id","u"."name" AS "login",count(*) FROM "user_info" "u" LEFT JOIN "org" "o" ON o.id = u.org_id AND o.name <> '?'` +
+				` WHERE (u.age > ? AND u.role = ?) AND (u.enable = 1) GROUP BY "u"."id" ORDER BY "u"."name" ASC LIMIT -1 OFFSET 20`,
+		},
+	}
+	for _, it := range cases {
+		sql, args := query.Render(it.dialect)
+		if sql != it.sql {
+			t.Fatalf("%s: expecting %s, got %s", it.dialect.Name(), it.sql, sql)
+		}
+		if !reflect.DeepEqual(args, []interface{}{18, "admin"}) {
+			t.Fatalf("%s: expecting args [18 admin], got %v", it.dialect.Name(), args)
+		}
+	}
+
+	query = mustBuild(t, newSQLQueryBuilder().
+		WithDialect(PostgreSQLDialect).
+		WithTable("product").WithField("id").
+		Where("price > ?", 10).
+		WithLimit(5).WithOffset(10))
+	if query.ToSQL() != `SELECT "id" FROM "product" WHERE price > $1 LIMIT 5 OFFSET 10` {
+		t.Fatalf("expecting builder dialect to be used by ToSQL, got %s", query.ToSQL())
+	}
+}
+
+func Test_DialectStatement(t *testing.T) {
+	expectSQL(t, mustBuild(t, newSQLUpdateBuilder().
+		WithDialect(PostgreSQLDialect).
+		WithTable("product").
+		WithSet("name", "tv").
+		WithSetExpr("stock", "stock - ?", 1).
+		Where("id = ?", 7)), PostgreSQLDialect,
+		`UPDATE "product" SET "name"=$1,"stock"=stock - $2 WHERE id = $3`, "tv", 1, 7)
+
+	expectSQL(t, mustBuild(t, newSQLInsertBuilder().
+		WithDialect(MySQLDialect).
+		WithTable("product").
+		WithColumns("id", "enable").
+		WithValues(1, BoolLiteral(false)).
+		OnDuplicateKeyUpdate("enable")), MySQLDialect,
+		"INSERT INTO `product` (`id`,`enable`) VALUES (?,FALSE) ON DUPLICATE KEY UPDATE `enable`=VALUES(`enable`)", 1)
+
+	expectSQL(t, mustBuild(t, newSQLDeleteBuilder().
+		WithDialect(SQLiteDialect).
+		WithTable("product").
+		Where("id = ?", 1)), SQLiteDialect,
+		`DELETE FROM "product" WHERE id = ?`, 1)
+}
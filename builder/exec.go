@@ -0,0 +1,224 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	ErrInvalidDest    = errors.New("dest must be a pointer to a struct, a slice of structs or a slice of struct pointers")
+	ErrUnmappedColumn = errors.New("column has no matching struct field")
+)
+
+// SQL 执行接口, *sql.DB 与 *sql.Tx 均实现了该接口
+type ISQLExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// 执行 INSERT/UPDATE/DELETE 等不返回结果集的语句
+func Exec(ctx context.Context, db ISQLExecutor, query ISQLQuery) (error, sql.Result) {
+	result, err := db.ExecContext(ctx, query.ToSQL(), query.Args()...)
+	if err != nil {
+		return err, nil
+	}
+	return nil, result
+}
+
+// 执行查询并将结果映射到 dest
+// dest 为 *[]T 或 *[]*T 时映射全部行; dest 为 *T 时只映射第一行, 没有结果时返回 sql.ErrNoRows
+// 列名按字段的 db 标签匹配, 没有标签的字段按 snake_case 形式的字段名匹配, db:"-" 表示忽略该字段
+func Query(ctx context.Context, db ISQLExecutor, query ISQLQuery, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ErrInvalidDest
+	}
+
+	target := v.Elem()
+	switch {
+	case target.Kind() == reflect.Struct:
+	case target.Kind() == reflect.Slice && isStructType(target.Type().Elem()):
+	default:
+		return ErrInvalidDest
+	}
+
+	rows, err := db.QueryContext(ctx, query.ToSQL(), query.Args()...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	if target.Kind() == reflect.Struct {
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return sql.ErrNoRows
+		}
+		if err := scanStruct(rows, target); err != nil {
+			return err
+		}
+		return rows.Close()
+	}
+
+	elemType := target.Type().Elem()
+	items := reflect.MakeSlice(target.Type(), 0, 0)
+	for rows.Next() {
+		item := reflect.New(derefType(elemType))
+		if err := scanStruct(rows, item.Elem()); err != nil {
+			return err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			items = reflect.Append(items, item)
+		} else {
+			items = reflect.Append(items, item.Elem())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	target.Set(items)
+	return nil
+}
+
+// 执行查询并将每一行映射为以列名为键的 map, []byte 类型的值会转换为 string
+func QueryMaps(ctx context.Context, db ISQLExecutor, query ISQLQuery) (error, []map[string]interface{}) {
+	rows, err := db.QueryContext(ctx, query.ToSQL(), query.Args()...)
+	if err != nil {
+		return err, nil
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err, nil
+	}
+
+	items := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return err, nil
+		}
+
+		item := make(map[string]interface{}, len(columns))
+		for i, it := range columns {
+			if b, ok := values[i].([]byte); ok {
+				item[it] = string(b)
+			} else {
+				item[it] = values[i]
+			}
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return err, nil
+	}
+	return nil, items
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+func isStructType(t reflect.Type) bool {
+	return derefType(t).Kind() == reflect.Struct
+}
+
+// 缓存每个结构体类型的列名到字段下标路径的映射
+var gStructColumns sync.Map
+
+func structColumns(t reflect.Type) map[string][]int {
+	if it, ok := gStructColumns.Load(t); ok {
+		return it.(map[string][]int)
+	}
+
+	columns := make(map[string][]int)
+	collectColumns(t, nil, columns)
+	gStructColumns.Store(t, columns)
+	return columns
+}
+
+// 匿名嵌入的结构体字段会被展开, 外层字段优先
+func collectColumns(t reflect.Type, prefix []int, columns map[string][]int) {
+	embedded := make([]reflect.StructField, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, f)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = toSnakeCase(f.Name)
+		}
+		if _, ok := columns[name]; !ok {
+			columns[name] = append(append([]int{}, prefix...), i)
+		}
+	}
+
+	for _, f := range embedded {
+		collectColumns(f.Type, append(append([]int{}, prefix...), f.Index...), columns)
+	}
+}
+
+// 将 OrgID 转换为 org_id, UserName 转换为 user_name
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	b := strings.Builder{}
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(c))
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func scanStruct(rows *sql.Rows, target reflect.Value) error {
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	columns := structColumns(target.Type())
+	pointers := make([]interface{}, len(names))
+	for i, it := range names {
+		index, ok := columns[it]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnmappedColumn, it)
+		}
+		pointers[i] = target.FieldByIndex(index).Addr().Interface()
+	}
+	return rows.Scan(pointers...)
+}
@@ -0,0 +1,160 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type auditInfo struct {
+	CreatedBy string
+}
+
+type userInfo struct {
+	auditInfo
+	ID       int
+	Name     string `db:"user_name"`
+	OrgID    int
+	Password string `db:"-"`
+}
+
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("mock error: '%s'", err)
+	}
+	return db, mock
+}
+
+func Test_ExecQuery(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("user_info").
+		WithField("id").WithField("user_name").WithField("org_id").WithField("created_by").
+		Where("org_id = ?", 11))
+	columns := []string{"id", "user_name", "org_id", "created_by"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(query.ToSQL())).WithArgs(11).WillReturnRows(
+		mock.NewRows(columns).AddRow(1, "John", 11, "admin").AddRow(2, "Mike", 11, "root"))
+	var users []userInfo
+	if err := Query(context.Background(), db, query, &users); err != nil {
+		t.Fatal(err)
+	}
+	expected := []userInfo{
+		{auditInfo: auditInfo{CreatedBy: "admin"}, ID: 1, Name: "John", OrgID: 11},
+		{auditInfo: auditInfo{CreatedBy: "root"}, ID: 2, Name: "Mike", OrgID: 11},
+	}
+	if !reflect.DeepEqual(users, expected) {
+		t.Fatalf("expecting %v, got %v", expected, users)
+	}
+
+	mock.ExpectQuery("SELECT").WithArgs(11).WillReturnRows(mock.NewRows(columns).AddRow(1, "John", 11, "admin"))
+	var pointers []*userInfo
+	if err := Query(context.Background(), db, query, &pointers); err != nil {
+		t.Fatal(err)
+	}
+	if len(pointers) != 1 || pointers[0].Name != "John" {
+		t.Fatalf("unexpected users %v", pointers)
+	}
+
+	mock.ExpectQuery("SELECT").WithArgs(11).WillReturnRows(mock.NewRows(columns).AddRow(1, "John", 11, "admin"))
+	var user userInfo
+	if err := Query(context.Background(), db, query, &user); err != nil || user.ID != 1 {
+		t.Fatalf("expecting user 1, got %v, %v", err, user)
+	}
+
+	mock.ExpectQuery("SELECT").WithArgs(11).WillReturnRows(mock.NewRows(columns))
+	if err := Query(context.Background(), db, query, &user); err != sql.ErrNoRows {
+		t.Fatalf("expecting sql.ErrNoRows, got %v", err)
+	}
+
+	mock.ExpectQuery("SELECT").WithArgs(11).WillReturnRows(mock.NewRows([]string{"id", "pwd"}).AddRow(1, "secret"))
+	if err := Query(context.Background(), db, query, &users); !errors.Is(err, ErrUnmappedColumn) {
+		t.Fatalf("expecting ErrUnmappedColumn, got %v", err)
+	}
+
+	if err := Query(context.Background(), db, query, users); err != ErrInvalidDest {
+		t.Fatalf("expecting ErrInvalidDest, got %v", err)
+	}
+
+	mock.ExpectQuery("SELECT").WithArgs(11).WillReturnRows(
+		mock.NewRows([]string{"id", "name"}).AddRow(1, []byte("John")).AddRow(2, nil))
+	err, maps := QueryMaps(context.Background(), db, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMaps := []map[string]interface{}{{"id": int64(1), "name": "John"}, {"id": int64(2), "name": nil}}
+	if !reflect.DeepEqual(maps, expectedMaps) {
+		t.Fatalf("expecting %v, got %v", expectedMaps, maps)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ExecTx(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	err, update := newSQLUpdateBuilder().
+		WithTable("user_info").
+		WithSet("org_id", 12).
+		Where("id = ?", 1).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(update.ToSQL())).WithArgs(12, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err, result := Exec(context.Background(), tx, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := result.RowsAffected(); n != 1 {
+		t.Fatalf("expecting 1 row affected, got %d", n)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// ctx 取消后不再执行查询
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var users []userInfo
+	if err := Query(ctx, db, mustBuild(t, newSQLQueryBuilder().WithTable("user_info").WithField("id")), &users); err == nil {
+		t.Fatal("expecting error for canceled context")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ToSnakeCase(t *testing.T) {
+	cases := map[string]string{"ID": "id", "OrgID": "org_id", "UserName": "user_name", "HTTPServer": "http_server", "name": "name"}
+	for name, expected := range cases {
+		if actual := toSnakeCase(name); actual != expected {
+			t.Fatalf("expecting %s -> %s, got %s", name, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package builder
+
+// 条件表达式接口, 表达式可组合为树, 渲染时按优先级自动加括号
+// 各节点类型均已导出, 可通过类型断言、Walk() 与 Rewrite() 在渲染前检查或改写表达式树
+type IExpr interface {
+	precedence() int
+	writeTo(b *sqlWriter)
+}
+
+// 表达式优先级, 子表达式优先级低于父表达式时需要加括号
+const (
+	precedenceRaw = iota // 原样输出的 SQL 片段无法判断优先级, 组合时总是加括号
+	precedenceOr
+	precedenceAnd
+	precedenceNot
+	precedencePredicate
+)
+
+// 原样输出的 SQL 片段, SQL 中的每个 ? 按顺序对应 Args 中的一个值
+type RawExpr struct {
+	SQL  string
+	Args []interface{}
+}
+
+func Raw(sql string, args ...interface{}) IExpr {
+	return &RawExpr{SQL: sql, Args: args}
+}
+
+func (r *RawExpr) precedence() int {
+	return precedenceRaw
+}
+
+func (r *RawExpr) writeTo(b *sqlWriter) {
+	b.writeRaw(r.SQL, r.Args)
+}
+
+// AND 组合, 没有子表达式时恒为真
+type AndExpr struct {
+	Items []IExpr
+}
+
+func And(items ...IExpr) IExpr {
+	return &AndExpr{Items: items}
+}
+
+func (a *AndExpr) precedence() int {
+	if len(a.Items) == 1 {
+		return a.Items[0].precedence()
+	}
+	return precedenceAnd
+}
+
+func (a *AndExpr) writeTo(b *sqlWriter) {
+	b.writeJunction(a.Items, " AND ", precedenceAnd, true)
+}
+
+// OR 组合, 没有子表达式时恒为假
+type OrExpr struct {
+	Items []IExpr
+}
+
+func Or(items ...IExpr) IExpr {
+	return &OrExpr{Items: items}
+}
+
+func (o *OrExpr) precedence() int {
+	if len(o.Items) == 1 {
+		return o.Items[0].precedence()
+	}
+	return precedenceOr
+}
+
+func (o *OrExpr) writeTo(b *sqlWriter) {
+	b.writeJunction(o.Items, " OR ", precedenceOr, false)
+}
+
+type NotExpr struct {
+	Item IExpr
+}
+
+func Not(item IExpr) IExpr {
+	return &NotExpr{Item: item}
+}
+
+func (n *NotExpr) precedence() int {
+	return precedenceNot
+}
+
+func (n *NotExpr) writeTo(b *sqlWriter) {
+	b.WriteString("NOT ")
+	b.writeExpr(n.Item, precedenceNot+1)
+}
+
+// 比较运算, Op 为 =、<>、!=、>、>=、<、<= 之一, Value 为 ISQLQuery 时作为标量子查询
+type CompareExpr struct {
+	Column string
+	Op     string
+	Value  interface{}
+}
+
+func compare(column string, op string, value interface{}) IExpr {
+	return &CompareExpr{Column: column, Op: op, Value: value}
+}
+
+func Eq(column string, value interface{}) IExpr {
+	return compare(column, "=", value)
+}
+
+func Ne(column string, value interface{}) IExpr {
+	return compare(column, "<>", value)
+}
+
+func Gt(column string, value interface{}) IExpr {
+	return compare(column, ">", value)
+}
+
+func Ge(column string, value interface{}) IExpr {
+	return compare(column, ">=", value)
+}
+
+func Lt(column string, value interface{}) IExpr {
+	return compare(column, "<", value)
+}
+
+func Le(column string, value interface{}) IExpr {
+	return compare(column, "<=", value)
+}
+
+func (c *CompareExpr) precedence() int {
+	return precedencePredicate
+}
+
+func (c *CompareExpr) writeTo(b *sqlWriter) {
+	b.writeIdentifier(c.Column)
+	b.WriteString(" ")
+	b.WriteString(c.Op)
+	b.WriteString(" ")
+	b.writeArg(c.Value)
+}
+
+// IN 列表或子查询, Query 不为 nil 时忽略 Values; 列表为空时 IN 恒为假, NOT IN 恒为真
+type InExpr struct {
+	Column string
+	Values []interface{}
+	Query  ISQLQuery
+	Not    bool
+}
+
+func In(column string, values ...interface{}) IExpr {
+	return &InExpr{Column: column, Values: values}
+}
+
+func NotIn(column string, values ...interface{}) IExpr {
+	return &InExpr{Column: column, Values: values, Not: true}
+}
+
+func InQuery(column string, query ISQLQuery) IExpr {
+	return &InExpr{Column: column, Query: query}
+}
+
+func NotInQuery(column string, query ISQLQuery) IExpr {
+	return &InExpr{Column: column, Query: query, Not: true}
+}
+
+func (i *InExpr) precedence() int {
+	return precedencePredicate
+}
+
+func (i *InExpr) writeTo(b *sqlWriter) {
+	if i.Query == nil && len(i.Values) == 0 {
+		b.writeBool(i.Not)
+		return
+	}
+
+	b.writeIdentifier(i.Column)
+	if i.Not {
+		b.WriteString(" NOT IN (")
+	} else {
+		b.WriteString(" IN (")
+	}
+	if i.Query != nil {
+		b.writeQuery(i.Query)
+		b.WriteString(")")
+		return
+	}
+	for j, it := range i.Values {
+		if j > 0 {
+			b.WriteString(",")
+		}
+		b.writeArg(it)
+	}
+	b.WriteString(")")
+}
+
+type BetweenExpr struct {
+	Column string
+	Low    interface{}
+	High   interface{}
+	Not    bool
+}
+
+func Between(column string, low interface{}, high interface{}) IExpr {
+	return &BetweenExpr{Column: column, Low: low, High: high}
+}
+
+func NotBetween(column string, low interface{}, high interface{}) IExpr {
+	return &BetweenExpr{Column: column, Low: low, High: high, Not: true}
+}
+
+func (e *BetweenExpr) precedence() int {
+	return precedencePredicate
+}
+
+func (e *BetweenExpr) writeTo(b *sqlWriter) {
+	b.writeIdentifier(e.Column)
+	if e.Not {
+		b.WriteString(" NOT BETWEEN ")
+	} else {
+		b.WriteString(" BETWEEN ")
+	}
+	b.writeArg(e.Low)
+	b.WriteString(" AND ")
+	b.writeArg(e.High)
+}
+
+type LikeExpr struct {
+	Column  string
+	Pattern string
+	Not     bool
+}
+
+func Like(column string, pattern string) IExpr {
+	return &LikeExpr{Column: column, Pattern: pattern}
+}
+
+func NotLike(column string, pattern string) IExpr {
+	return &LikeExpr{Column: column, Pattern: pattern, Not: true}
+}
+
+func (l *LikeExpr) precedence() int {
+	return precedencePredicate
+}
+
+func (l *LikeExpr) writeTo(b *sqlWriter) {
+	b.writeIdentifier(l.Column)
+	if l.Not {
+		b.WriteString(" NOT LIKE ")
+	} else {
+		b.WriteString(" LIKE ")
+	}
+	b.writeArg(l.Pattern)
+}
+
+type IsNullExpr struct {
+	Column string
+	Not    bool
+}
+
+func IsNull(column string) IExpr {
+	return &IsNullExpr{Column: column}
+}
+
+func IsNotNull(column string) IExpr {
+	return &IsNullExpr{Column: column, Not: true}
+}
+
+func (i *IsNullExpr) precedence() int {
+	return precedencePredicate
+}
+
+func (i *IsNullExpr) writeTo(b *sqlWriter) {
+	b.writeIdentifier(i.Column)
+	if i.Not {
+		b.WriteString(" IS NOT NULL")
+	} else {
+		b.WriteString(" IS NULL")
+	}
+}
+
+type ExistsExpr struct {
+	Query ISQLQuery
+	Not   bool
+}
+
+func Exists(query ISQLQuery) IExpr {
+	return &ExistsExpr{Query: query}
+}
+
+func NotExists(query ISQLQuery) IExpr {
+	return &ExistsExpr{Query: query, Not: true}
+}
+
+func (e *ExistsExpr) precedence() int {
+	return precedencePredicate
+}
+
+func (e *ExistsExpr) writeTo(b *sqlWriter) {
+	if e.Not {
+		b.WriteString("NOT EXISTS (")
+	} else {
+		b.WriteString("EXISTS (")
+	}
+	b.writeQuery(e.Query)
+	b.WriteString(")")
+}
+
+// 前序遍历表达式树, fn 返回 false 时不再深入该节点的子表达式
+func Walk(expr IExpr, fn func(expr IExpr) bool) {
+	if expr == nil || !fn(expr) {
+		return
+	}
+
+	switch it := expr.(type) {
+	case *AndExpr:
+		for _, item := range it.Items {
+			Walk(item, fn)
+		}
+	case *OrExpr:
+		for _, item := range it.Items {
+			Walk(item, fn)
+		}
+	case *NotExpr:
+		Walk(it.Item, fn)
+	}
+}
+
+// 自底向上改写表达式树, 返回新的表达式树而不修改原有节点
+// fn 返回 nil 表示删除该节点, 删除后为空的 AND/OR 以及子表达式被删除的 NOT 也会被删除
+func Rewrite(expr IExpr, fn func(expr IExpr) IExpr) IExpr {
+	if expr == nil {
+		return nil
+	}
+
+	switch it := expr.(type) {
+	case *AndExpr:
+		items := rewriteItems(it.Items, fn)
+		if len(it.Items) > 0 && len(items) == 0 {
+			return nil
+		}
+		expr = &AndExpr{Items: items}
+	case *OrExpr:
+		items := rewriteItems(it.Items, fn)
+		if len(it.Items) > 0 && len(items) == 0 {
+			return nil
+		}
+		expr = &OrExpr{Items: items}
+	case *NotExpr:
+		item := Rewrite(it.Item, fn)
+		if item == nil {
+			return nil
+		}
+		expr = &NotExpr{Item: item}
+	}
+	return fn(expr)
+}
+
+func rewriteItems(items []IExpr, fn func(expr IExpr) IExpr) []IExpr {
+	result := make([]IExpr, 0, len(items))
+	for _, it := range items {
+		if rewritten := Rewrite(it, fn); rewritten != nil {
+			result = append(result, rewritten)
+		}
+	}
+	return result
+}
+
+// 深拷贝表达式树, 使已构建的查询不受调用方之后修改节点的影响
+// 子查询本身是已构建的不可变查询, 不需要拷贝
+func cloneExpr(expr IExpr) IExpr {
+	return Rewrite(expr, func(expr IExpr) IExpr {
+		switch it := expr.(type) {
+		case *RawExpr:
+			c := *it
+			c.Args = append([]interface{}(nil), it.Args...)
+			return &c
+		case *CompareExpr:
+			c := *it
+			return &c
+		case *InExpr:
+			c := *it
+			c.Values = append([]interface{}(nil), it.Values...)
+			return &c
+		case *BetweenExpr:
+			c := *it
+			return &c
+		case *LikeExpr:
+			c := *it
+			return &c
+		case *IsNullExpr:
+			c := *it
+			return &c
+		case *ExistsExpr:
+			c := *it
+			return &c
+		}
+		// AND/OR/NOT 已由 Rewrite 重新创建
+		return expr
+	})
+}
+
+func cloneExprs(items []IExpr) []IExpr {
+	result := make([]IExpr, len(items))
+	for i, it := range items {
+		result[i] = cloneExpr(it)
+	}
+	return result
+}
+
+func (b *sqlWriter) writeBool(value bool) {
+	b.WriteString(b.dialect.Bool(value))
+}
+
+// 输出子表达式, 其优先级低于 parent 时加括号
+func (b *sqlWriter) writeExpr(expr IExpr, parent int) {
+	if expr.precedence() < parent {
+		b.WriteString("(")
+		expr.writeTo(b)
+		b.WriteString(")")
+	} else {
+		expr.writeTo(b)
+	}
+}
+
+func (b *sqlWriter) writeJunction(items []IExpr, sep string, precedence int, empty bool) {
+	if len(items) == 0 {
+		b.writeBool(empty)
+		return
+	}
+	if len(items) == 1 {
+		items[0].writeTo(b)
+		return
+	}
+
+	for i, it := range items {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.writeExpr(it, precedence)
+	}
+}
+
+// 将子查询输出到当前缓冲区, 子查询的参数按出现位置合并
+func (b *sqlWriter) writeQuery(query ISQLQuery) {
+	if it, ok := query.(interface{ writeTo(b *sqlWriter) }); ok {
+		it.writeTo(b)
+		return
+	}
+
+	// 其他 ISQLQuery 实现按通用方言渲染, 再把 ? 替换为当前方言的占位符
+	sql, args := query.Render(GenericDialect)
+	b.writeRaw(sql, args)
+}
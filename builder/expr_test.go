@@ -0,0 +1,103 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Expr(t *testing.T) {
+	expectSQL(t, Eq("name", "tv"), GenericDialect, "name = ?", "tv")
+	expectSQL(t, And(Gt("age", 18), Le("age", 60)), GenericDialect, "age > ? AND age <= ?", 18, 60)
+	expectSQL(t, Or(Eq("a", 1), And(Eq("b", 2), Eq("c", 3))), GenericDialect, "a = ? OR b = ? AND c = ?", 1, 2, 3)
+	expectSQL(t, And(Eq("a", 1), Or(Eq("b", 2), Eq("c", 3))), GenericDialect, "a = ? AND (b = ? OR c = ?)", 1, 2, 3)
+	expectSQL(t, Not(Or(IsNull("a"), IsNotNull("b"))), GenericDialect, "NOT (a IS NULL OR b IS NOT NULL)")
+	expectSQL(t, Not(Not(Eq("a", 1))), GenericDialect, "NOT (NOT a = ?)", 1)
+	expectSQL(t, And(Or(Eq("a", 1)), Raw("x = ? OR y = ?", 2, 3)), GenericDialect, "a = ? AND (x = ? OR y = ?)", 1, 2, 3)
+	expectSQL(t, Or(Eq("a", 1)), GenericDialect, "a = ?", 1)
+	expectSQL(t, And(), SQLiteDialect, "1")
+	expectSQL(t, Or(), PostgreSQLDialect, "FALSE")
+
+	expectSQL(t, In("city", "广州", "深圳"), PostgreSQLDialect, `"city" IN ($1,$2)`, "广州", "深圳")
+	expectSQL(t, NotIn("id", 1), GenericDialect, "id NOT IN (?)", 1)
+	expectSQL(t, In("id"), GenericDialect, "FALSE")
+	expectSQL(t, NotIn("id"), GenericDialect, "TRUE")
+	expectSQL(t, Between("price", 10, 20), MySQLDialect, "`price` BETWEEN ? AND ?", 10, 20)
+	expectSQL(t, NotBetween("price", 10, 20), GenericDialect, "price NOT BETWEEN ? AND ?", 10, 20)
+	expectSQL(t, Like("name", "%tv%"), GenericDialect, "name LIKE ?", "%tv%")
+	expectSQL(t, NotLike("name", "%tv%"), GenericDialect, "name NOT LIKE ?", "%tv%")
+	expectSQL(t, Eq("enable", BoolLiteral(true)), SQLiteDialect, `"enable" = 1`)
+
+	sub := mustBuild(t, newSQLQueryBuilder().
+		WithTable("orders").WithField("id").
+		Where("orders.user_id = user_info.id AND orders.amount > ?", 100))
+	expectSQL(t, And(Eq("org_id", 7), Exists(sub)), PostgreSQLDialect,
+		`"org_id" = $1 AND EXISTS (SELECT "id" FROM "orders" WHERE orders.user_id = user_info.id AND orders.amount > $2)`, 7, 100)
+	expectSQL(t, NotExists(sub), GenericDialect,
+		"NOT EXISTS (SELECT id FROM orders WHERE orders.user_id = user_info.id AND orders.amount > ?)", 100)
+}
+
+func Test_ExprBuilder(t *testing.T) {
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("user_info").WithField("id").
+		WithCondition("enable=1").
+		WhereExpr(Or(Eq("city", "广州"), And(Gt("age", 18), Like("name", "张%")))).
+		WithGroupBy("city").
+		WithHavingExpr(Gt("count(*)", 1)))
+
+	sql, args := query.Render(PostgreSQLDialect)
+	expected := `SELECT "id" FROM "user_info" WHERE (enable=1) AND ("city" = $1 OR "age" > $2 AND "name" LIKE $3) GROUP BY "city" HAVING count(*) > $4`
+	if sql != expected {
+		t.Fatalf("expecting %s, got %s", expected, sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"广州", 18, "张%", 1}) {
+		t.Fatalf("unexpected args %v", args)
+	}
+
+	expectSQL(t, mustBuild(t, newSQLUpdateBuilder().
+		WithTable("user_info").
+		WithSet("enable", 0).
+		WhereExpr(In("id", 1, 2))), GenericDialect,
+		"UPDATE user_info SET enable=? WHERE id IN (?,?)", 0, 1, 2)
+
+	expectSQL(t, mustBuild(t, newSQLDeleteBuilder().
+		WithTable("user_info").
+		WhereExpr(Or(IsNull("org_id"), Eq("enable", 0)))), GenericDialect,
+		"DELETE FROM user_info WHERE org_id IS NULL OR enable = ?", 0)
+}
+
+func Test_ExprRewrite(t *testing.T) {
+	expr := And(Eq("city", "广州"), Or(Eq("tenant_id", 1), Gt("age", 18)), Not(Eq("tenant_id", 2)))
+
+	// 收集表达式中出现的列
+	columns := make([]string, 0)
+	Walk(expr, func(it IExpr) bool {
+		if c, ok := it.(*CompareExpr); ok {
+			columns = append(columns, c.Column)
+		}
+		return true
+	})
+	if !reflect.DeepEqual(columns, []string{"city", "tenant_id", "age", "tenant_id"}) {
+		t.Fatalf("unexpected columns %v", columns)
+	}
+
+	// 删除所有 tenant_id 条件, 并将 city 条件改写为 IN
+	rewritten := Rewrite(expr, func(it IExpr) IExpr {
+		if c, ok := it.(*CompareExpr); ok {
+			switch c.Column {
+			case "tenant_id":
+				return nil
+			case "city":
+				return In("city", c.Value, "深圳")
+			}
+		}
+		return it
+	})
+	expectSQL(t, rewritten, GenericDialect, "city IN (?,?) AND age > ?", "广州", "深圳", 18)
+
+	// 原表达式树不受影响
+	expectSQL(t, expr, GenericDialect, "city = ? AND (tenant_id = ? OR age > ?) AND NOT tenant_id = ?", "广州", 1, 18, 2)
+
+	if Rewrite(expr, func(it IExpr) IExpr { return nil }) != nil {
+		t.Fatal("expecting everything to be removed")
+	}
+}
@@ -0,0 +1,1004 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrSyntax        = errors.New("sql syntax error")
+	ErrArgCount      = errors.New("argument count does not match placeholders")
+	errNotStructured = errors.New("not a structured predicate")
+)
+
+// 将建造者输出的 SELECT 语句解析回建造者, 以便继续修改后重新构建
+// 支持 WITH、子查询、JOIN、WHERE、GROUP BY、HAVING、集合运算、ORDER BY 与 LIMIT/OFFSET
+// 标识符可以使用反引号或双引号引用, 占位符可以是 ? 或 $n, args 为占位符对应的绑定参数
+// 条件会被解析为表达式树, 无法识别的条件(例如两列之间的比较)保留为 RawExpr
+func Parse(sql string, args ...interface{}) (error, ISQLQueryBuilder) {
+	return ParseDialect(GenericDialect, sql, args...)
+}
+
+// 与 Parse 相同, 返回的建造者使用指定的方言
+func ParseDialect(dialect IDialect, sql string, args ...interface{}) (error, ISQLQueryBuilder) {
+	err, tokens := tokenize(sql)
+	if err != nil {
+		return err, nil
+	}
+
+	p := &sqlParser{
+		sql:     sql,
+		tokens:  tokens,
+		args:    args,
+		dialect: dialect,
+	}
+	err, builder := p.parseQuery()
+	if err != nil {
+		return err, nil
+	}
+	if p.peek().kind != tokenEOF {
+		return p.errorf("unexpected %q", p.peek().text), nil
+	}
+	if p.next+p.maxArg != len(args) || (p.next > 0 && p.maxArg > 0) {
+		return fmt.Errorf("%w: %d args", ErrArgCount, len(args)), nil
+	}
+	return nil, builder
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenPlaceholder
+	tokenSymbol
+)
+
+type sqlToken struct {
+	kind  tokenKind
+	text  string // 引用的标识符与字符串为去掉引号后的内容, 其他为原文
+	start int
+	end   int
+}
+
+var twoCharSymbols = []string{"<>", "!=", "<=", ">=", "||"}
+
+func tokenize(sql string) (error, []sqlToken) {
+	tokens := make([]sqlToken, 0)
+	for i := 0; i < len(sql); {
+		r, size := utf8.DecodeRuneInString(sql[i:])
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(sql) {
+				r, size = utf8.DecodeRuneInString(sql[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, sqlToken{kind: tokenIdent, text: sql[start:i], start: start, end: i})
+
+		case r >= '0' && r <= '9':
+			for i < len(sql) && (sql[i] >= '0' && sql[i] <= '9' || sql[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenNumber, text: sql[start:i], start: start, end: i})
+
+		case r == '\'' || r == '"' || r == '`':
+			err, text, end := readQuoted(sql, i, byte(r))
+			if err != nil {
+				return err, nil
+			}
+			kind := tokenQuotedIdent
+			if r == '\'' {
+				kind = tokenString
+			}
+			i = end
+			tokens = append(tokens, sqlToken{kind: kind, text: text, start: start, end: i})
+
+		case r == '?':
+			i++
+			tokens = append(tokens, sqlToken{kind: tokenPlaceholder, text: "?", start: start, end: i})
+
+		case r == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			i++
+			for i < len(sql) && sql[i] >= '0' && sql[i] <= '9' {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenPlaceholder, text: sql[start:i], start: start, end: i})
+
+		default:
+			text := string(r)
+			for _, it := range twoCharSymbols {
+				if strings.HasPrefix(sql[i:], it) {
+					text = it
+					break
+				}
+			}
+			if !strings.ContainsAny(text, "(),.*=<>!+-/%|;") {
+				return fmt.Errorf("%w at %d: unexpected %q", ErrSyntax, i, text), nil
+			}
+			i += len(text)
+			tokens = append(tokens, sqlToken{kind: tokenSymbol, text: text, start: start, end: i})
+		}
+	}
+	return nil, append(tokens, sqlToken{kind: tokenEOF, start: len(sql), end: len(sql)})
+}
+
+// 读取引号内的内容, 连续两个引号表示引号本身
+func readQuoted(sql string, start int, quote byte) (error, string, int) {
+	b := strings.Builder{}
+	for i := start + 1; i < len(sql); i++ {
+		if sql[i] != quote {
+			b.WriteByte(sql[i])
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == quote {
+			b.WriteByte(quote)
+			i++
+			continue
+		}
+		return nil, b.String(), i + 1
+	}
+	return fmt.Errorf("%w at %d: unterminated quote", ErrSyntax, start), "", 0
+}
+
+// 递归下降解析器
+type sqlParser struct {
+	sql     string
+	tokens  []sqlToken
+	pos     int
+	args    []interface{}
+	next    int // 下一个 ? 占位符对应的参数下标
+	maxArg  int // $n 占位符中最大的 n
+	dialect IDialect
+}
+
+// 解析器的回溯点
+type parserState struct {
+	pos    int
+	next   int
+	maxArg int
+}
+
+func (p *sqlParser) save() parserState {
+	return parserState{pos: p.pos, next: p.next, maxArg: p.maxArg}
+}
+
+func (p *sqlParser) restore(state parserState) {
+	p.pos, p.next, p.maxArg = state.pos, state.next, state.maxArg
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) peekAt(offset int) sqlToken {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *sqlParser) advance() sqlToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *sqlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w at %d: %s", ErrSyntax, p.peek().start, fmt.Sprintf(format, args...))
+}
+
+func isKeyword(tok sqlToken, keyword string) bool {
+	return tok.kind == tokenIdent && strings.EqualFold(tok.text, keyword)
+}
+
+func isSymbol(tok sqlToken, symbol string) bool {
+	return tok.kind == tokenSymbol && tok.text == symbol
+}
+
+// 依次匹配多个关键字, 全部匹配时才消费
+func (p *sqlParser) acceptKeyword(keywords ...string) bool {
+	for i, it := range keywords {
+		if !isKeyword(p.peekAt(i), it) {
+			return false
+		}
+	}
+	p.pos += len(keywords)
+	return true
+}
+
+func (p *sqlParser) expectKeyword(keywords ...string) error {
+	if !p.acceptKeyword(keywords...) {
+		return p.errorf("expecting %s", strings.Join(keywords, " "))
+	}
+	return nil
+}
+
+func (p *sqlParser) acceptSymbol(symbol string) bool {
+	if isSymbol(p.peek(), symbol) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return p.errorf("expecting %q", symbol)
+	}
+	return nil
+}
+
+// 子句关键字, 用于判断字段列表、条件等的结束位置
+var clauseKeywords = []string{
+	"FROM", "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET",
+	"UNION", "INTERSECT", "EXCEPT", "JOIN", "INNER", "LEFT", "RIGHT", "ON",
+}
+
+func isClauseKeyword(tok sqlToken) bool {
+	for _, it := range clauseKeywords {
+		if isKeyword(tok, it) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *sqlParser) lookupArg(tok sqlToken) (error, interface{}) {
+	if tok.text == "?" {
+		if p.next >= len(p.args) {
+			return fmt.Errorf("%w: missing argument for placeholder %d", ErrArgCount, p.next+1), nil
+		}
+		p.next++
+		return nil, p.args[p.next-1]
+	}
+
+	index, err := strconv.Atoi(tok.text[1:])
+	if err != nil || index < 1 || index > len(p.args) {
+		return fmt.Errorf("%w: missing argument for placeholder %s", ErrArgCount, tok.text), nil
+	}
+	if index > p.maxArg {
+		p.maxArg = index
+	}
+	return nil, p.args[index-1]
+}
+
+func (p *sqlParser) newBuilder() ISQLQueryBuilder {
+	return newSQLQueryBuilder().WithDialect(p.dialect)
+}
+
+// query := [WITH cte, ...] core (setop member)* [ORDER BY ...] [LIMIT n] [OFFSET n]
+func (p *sqlParser) parseQuery() (error, ISQLQueryBuilder) {
+	b := p.newBuilder()
+
+	if p.acceptKeyword("WITH") {
+		for {
+			err, name := p.parseName()
+			if err != nil {
+				return err, nil
+			}
+			if err := p.expectKeyword("AS"); err != nil {
+				return err, nil
+			}
+			err, sub := p.parseSubquery()
+			if err != nil {
+				return err, nil
+			}
+			b.WithCTE(name, sub)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if err := p.parseCore(b); err != nil {
+		return err, nil
+	}
+
+	for {
+		op, ok := p.acceptSetOperator()
+		if !ok {
+			break
+		}
+
+		if isSymbol(p.peek(), "(") {
+			err, sub := p.parseSubquery()
+			if err != nil {
+				return err, nil
+			}
+			b.WithSetOperation(op, sub)
+		} else {
+			member := p.newBuilder()
+			if err := p.parseCore(member); err != nil {
+				return err, nil
+			}
+			err, built := member.Build()
+			if err != nil {
+				return err, nil
+			}
+			b.WithSetOperation(op, built)
+		}
+	}
+
+	if p.acceptKeyword("ORDER", "BY") {
+		for {
+			if err := p.parseOrderItem(b); err != nil {
+				return err, nil
+			}
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		err, limit := p.parseCount()
+		if err != nil {
+			return err, nil
+		}
+		// LIMIT -1 与 MySQL 的最大值只用于配合 OFFSET, 表示不限制
+		if limit >= 0 {
+			b.WithLimit(limit)
+		}
+	}
+
+	if p.acceptKeyword("OFFSET") {
+		err, offset := p.parseCount()
+		if err != nil {
+			return err, nil
+		}
+		b.WithOffset(offset)
+	}
+
+	return nil, b
+}
+
+// "(" query ")"
+func (p *sqlParser) parseSubquery() (error, ISQLQuery) {
+	if err := p.expectSymbol("("); err != nil {
+		return err, nil
+	}
+	err, sub := p.parseQuery()
+	if err != nil {
+		return err, nil
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return err, nil
+	}
+	return sub.Build()
+}
+
+func (p *sqlParser) acceptSetOperator() (SetOperator, bool) {
+	switch {
+	case p.acceptKeyword("UNION", "ALL"):
+		return UnionAll, true
+	case p.acceptKeyword("UNION"):
+		return Union, true
+	case p.acceptKeyword("INTERSECT"):
+		return Intersect, true
+	case p.acceptKeyword("EXCEPT"):
+		return Except, true
+	}
+	return "", false
+}
+
+// 解析 LIMIT/OFFSET 的数值, MySQL 的最大值与 SQLite 的 -1 均表示不限制
+func (p *sqlParser) parseCount() (error, int) {
+	negative := p.acceptSymbol("-")
+	tok := p.advance()
+	if tok.kind != tokenNumber {
+		return p.errorf("expecting number"), 0
+	}
+	if negative || tok.text == "18446744073709551615" {
+		return nil, -1
+	}
+
+	n, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return p.errorf("invalid number %q", tok.text), 0
+	}
+	return nil, n
+}
+
+// core := SELECT fields FROM source joins [WHERE expr] [GROUP BY ...] [HAVING expr]
+func (p *sqlParser) parseCore(b ISQLQueryBuilder) error {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return err
+	}
+
+	for {
+		err, field := p.parseListItem(true)
+		if err != nil {
+			return err
+		}
+		b.WithField(field)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return err
+	}
+	if isSymbol(p.peek(), "(") {
+		err, sub := p.parseSubquery()
+		if err != nil {
+			return err
+		}
+		p.acceptKeyword("AS")
+		err, alias := p.parseName()
+		if err != nil {
+			return err
+		}
+		b.WithTableQuery(sub, alias)
+	} else {
+		err, table := p.parseTableRef()
+		if err != nil {
+			return err
+		}
+		b.WithTable(table)
+	}
+
+	for {
+		joinType, ok := p.acceptJoin()
+		if !ok {
+			break
+		}
+		err, table := p.parseTableRef()
+		if err != nil {
+			return err
+		}
+		if err := p.expectKeyword("ON"); err != nil {
+			return err
+		}
+		err, on := p.parseExpr()
+		if err != nil {
+			return err
+		}
+		b.WithJoinExpr(joinType, table, on)
+	}
+
+	if p.acceptKeyword("WHERE") {
+		err, expr := p.parseExpr()
+		if err != nil {
+			return err
+		}
+		for _, it := range splitAnd(expr) {
+			b.WhereExpr(it)
+		}
+	}
+
+	if p.acceptKeyword("GROUP", "BY") {
+		for {
+			err, field := p.parseListItem(false)
+			if err != nil {
+				return err
+			}
+			b.WithGroupBy(field)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("HAVING") {
+		err, expr := p.parseExpr()
+		if err != nil {
+			return err
+		}
+		for _, it := range splitAnd(expr) {
+			b.WithHavingExpr(it)
+		}
+	}
+
+	return nil
+}
+
+// 顶层的 AND 拆分为多个条件
+func splitAnd(expr IExpr) []IExpr {
+	if it, ok := expr.(*AndExpr); ok && len(it.Items) > 1 {
+		return it.Items
+	}
+	return []IExpr{expr}
+}
+
+func (p *sqlParser) acceptJoin() (JoinType, bool) {
+	switch {
+	case p.acceptKeyword("JOIN"), p.acceptKeyword("INNER", "JOIN"):
+		return InnerJoin, true
+	case p.acceptKeyword("LEFT", "JOIN"), p.acceptKeyword("LEFT", "OUTER", "JOIN"):
+		return LeftJoin, true
+	case p.acceptKeyword("RIGHT", "JOIN"), p.acceptKeyword("RIGHT", "OUTER", "JOIN"):
+		return RightJoin, true
+	}
+	return "", false
+}
+
+// 单个标识符
+func (p *sqlParser) parseName() (error, string) {
+	tok := p.peek()
+	if tok.kind == tokenQuotedIdent || (tok.kind == tokenIdent && !isClauseKeyword(tok)) {
+		p.advance()
+		return nil, tok.text
+	}
+	return p.errorf("expecting identifier"), ""
+}
+
+// 以 . 分隔的标识符路径, 最后一段可以是 *
+func (p *sqlParser) parsePath() (string, bool) {
+	state := p.save()
+	segments := make([]string, 0)
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenIdent || tok.kind == tokenQuotedIdent:
+			segments = append(segments, tok.text)
+			p.advance()
+		case isSymbol(tok, "*"):
+			segments = append(segments, "*")
+			p.advance()
+			return strings.Join(segments, "."), true
+		default:
+			p.restore(state)
+			return "", false
+		}
+		if !isSymbol(p.peek(), ".") {
+			return strings.Join(segments, "."), true
+		}
+		p.advance()
+	}
+}
+
+// table [AS] [alias]
+func (p *sqlParser) parseTableRef() (error, string) {
+	tok := p.peek()
+	if tok.kind != tokenIdent && tok.kind != tokenQuotedIdent {
+		return p.errorf("expecting table"), ""
+	}
+	table, _ := p.parsePath()
+
+	if as := p.peek(); isKeyword(as, "AS") {
+		p.advance()
+		err, alias := p.parseName()
+		if err != nil {
+			return err, ""
+		}
+		return nil, table + " " + as.text + " " + alias
+	}
+
+	next := p.peek()
+	if next.kind == tokenQuotedIdent || (next.kind == tokenIdent && !isClauseKeyword(next)) {
+		p.advance()
+		return nil, table + " " + next.text
+	}
+	return nil, table
+}
+
+// 收集到深度为 0 的逗号、右括号、子句关键字或结尾之前的记号
+func (p *sqlParser) collectItem() (int, int) {
+	start, depth := p.pos, 0
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			break
+		}
+		if depth == 0 && (isSymbol(tok, ",") || isSymbol(tok, ")") || isClauseKeyword(tok)) {
+			break
+		}
+		if isSymbol(tok, "(") {
+			depth++
+		} else if isSymbol(tok, ")") {
+			depth--
+		}
+		p.advance()
+	}
+	return start, p.pos
+}
+
+// 字段或分组项: 标识符路径(可带别名)还原为未引用的形式, 其他表达式保留原文
+func (p *sqlParser) parseListItem(allowAlias bool) (error, string) {
+	start, end := p.collectItem()
+	if start == end {
+		return p.errorf("expecting expression"), ""
+	}
+	return nil, p.itemText(start, end, allowAlias)
+}
+
+func (p *sqlParser) itemText(start int, end int, allowAlias bool) string {
+	state := p.save()
+	defer p.restore(state)
+
+	p.pos = start
+	if path, ok := p.parsePath(); ok {
+		switch {
+		case p.pos == end:
+			return path
+		case allowAlias && p.pos+1 == end && isIdentToken(p.peek()):
+			return path + " " + p.peek().text
+		case allowAlias && p.pos+2 == end && isKeyword(p.peek(), "AS") && isIdentToken(p.peekAt(1)):
+			return path + " " + p.peek().text + " " + p.peekAt(1).text
+		}
+	}
+	return p.sql[p.tokens[start].start:p.tokens[end-1].end]
+}
+
+func isIdentToken(tok sqlToken) bool {
+	return tok.kind == tokenIdent || tok.kind == tokenQuotedIdent
+}
+
+// 排序项, 字段路径以 ASC/DESC 结尾时解析为字段与方向, 否则(包括函数调用等表达式)保留原文
+func (p *sqlParser) parseOrderItem(b ISQLQueryBuilder) error {
+	start, end := p.collectItem()
+	if start == end {
+		return p.errorf("expecting expression")
+	}
+
+	last := p.tokens[end-1]
+	if end-start > 1 && (isKeyword(last, "ASC") || isKeyword(last, "DESC")) {
+		field := p.itemText(start, end-1, false)
+		if _, ok := quotePath(GenericDialect, field); ok && !strings.HasSuffix(field, "*") {
+			b.WithOrder(field, SortDirection(strings.ToUpper(last.text)))
+			return nil
+		}
+	}
+	b.WithOrderBy(p.sql[p.tokens[start].start:last.end])
+	return nil
+}
+
+// expr := and (OR and)*
+func (p *sqlParser) parseExpr() (error, IExpr) {
+	err, first := p.parseAnd()
+	if err != nil {
+		return err, nil
+	}
+
+	items := []IExpr{first}
+	for p.acceptKeyword("OR") {
+		err, it := p.parseAnd()
+		if err != nil {
+			return err, nil
+		}
+		items = append(items, it)
+	}
+	if len(items) == 1 {
+		return nil, first
+	}
+	return nil, Or(items...)
+}
+
+// and := not (AND not)*
+func (p *sqlParser) parseAnd() (error, IExpr) {
+	err, first := p.parseNot()
+	if err != nil {
+		return err, nil
+	}
+
+	items := []IExpr{first}
+	for p.acceptKeyword("AND") {
+		err, it := p.parseNot()
+		if err != nil {
+			return err, nil
+		}
+		items = append(items, it)
+	}
+	if len(items) == 1 {
+		return nil, first
+	}
+	return nil, And(items...)
+}
+
+// not := NOT not | predicate
+func (p *sqlParser) parseNot() (error, IExpr) {
+	if isKeyword(p.peek(), "NOT") && !isKeyword(p.peekAt(1), "EXISTS") {
+		p.advance()
+		err, it := p.parseNot()
+		if err != nil {
+			return err, nil
+		}
+		return nil, Not(it)
+	}
+	return p.parsePredicate()
+}
+
+// 条件的结束位置
+func isExprEnd(tok sqlToken) bool {
+	return tok.kind == tokenEOF || isKeyword(tok, "AND") || isKeyword(tok, "OR") ||
+		isSymbol(tok, ")") || isClauseKeyword(tok)
+}
+
+// 先尝试解析为结构化的谓词, 失败时回溯并将整个谓词保留为 RawExpr
+func (p *sqlParser) parsePredicate() (error, IExpr) {
+	state := p.save()
+	err, expr := p.parseStructured()
+	if err == nil && isExprEnd(p.peek()) {
+		return nil, expr
+	}
+	if err != nil && !errors.Is(err, errNotStructured) && !errors.Is(err, ErrSyntax) {
+		return err, nil
+	}
+
+	p.restore(state)
+	return p.parseRaw()
+}
+
+func (p *sqlParser) parseStructured() (error, IExpr) {
+	tok := p.peek()
+
+	if isSymbol(tok, "(") {
+		next := p.peekAt(1)
+		if isKeyword(next, "SELECT") || isKeyword(next, "WITH") {
+			return errNotStructured, nil
+		}
+		p.advance()
+		err, inner := p.parseExpr()
+		if err != nil {
+			return err, nil
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return err, nil
+		}
+		return nil, inner
+	}
+
+	if p.acceptKeyword("EXISTS") {
+		err, sub := p.parseSubquery()
+		if err != nil {
+			return err, nil
+		}
+		return nil, Exists(sub)
+	}
+	if p.acceptKeyword("NOT", "EXISTS") {
+		err, sub := p.parseSubquery()
+		if err != nil {
+			return err, nil
+		}
+		return nil, NotExists(sub)
+	}
+
+	if isKeyword(tok, "TRUE") && isExprEnd(p.peekAt(1)) {
+		p.advance()
+		return nil, And()
+	}
+	if isKeyword(tok, "FALSE") && isExprEnd(p.peekAt(1)) {
+		p.advance()
+		return nil, Or()
+	}
+
+	err, column := p.parseColumn()
+	if err != nil {
+		return err, nil
+	}
+
+	if p.acceptKeyword("IS", "NULL") {
+		return nil, IsNull(column)
+	}
+	if p.acceptKeyword("IS", "NOT", "NULL") {
+		return nil, IsNotNull(column)
+	}
+
+	not := p.acceptKeyword("NOT")
+	switch {
+	case p.acceptKeyword("IN"):
+		return p.parseIn(column, not)
+
+	case p.acceptKeyword("BETWEEN"):
+		err, low := p.parseValue()
+		if err != nil {
+			return err, nil
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return err, nil
+		}
+		err, high := p.parseValue()
+		if err != nil {
+			return err, nil
+		}
+		if not {
+			return nil, NotBetween(column, low, high)
+		}
+		return nil, Between(column, low, high)
+
+	case p.acceptKeyword("LIKE"):
+		err, value := p.parseValue()
+		if err != nil {
+			return err, nil
+		}
+		pattern, ok := value.(string)
+		if !ok {
+			return errNotStructured, nil
+		}
+		if not {
+			return nil, NotLike(column, pattern)
+		}
+		return nil, Like(column, pattern)
+
+	case not:
+		return errNotStructured, nil
+	}
+
+	op := p.peek()
+	if op.kind != tokenSymbol {
+		return errNotStructured, nil
+	}
+	switch op.text {
+	case "=", "<>", ">", ">=", "<", "<=":
+	case "!=":
+		op.text = "<>"
+	default:
+		return errNotStructured, nil
+	}
+	p.advance()
+
+	err, value := p.parseValue()
+	if err != nil {
+		return err, nil
+	}
+	return nil, compare(column, op.text, value)
+}
+
+// 列名为标识符路径或函数调用, 例如 u.name、count(*)
+func (p *sqlParser) parseColumn() (error, string) {
+	start := p.pos
+	path, ok := p.parsePath()
+	if !ok {
+		return errNotStructured, ""
+	}
+	if !isSymbol(p.peek(), "(") {
+		return nil, path
+	}
+
+	depth := 0
+	for {
+		tok := p.advance()
+		switch {
+		case tok.kind == tokenEOF:
+			return errNotStructured, ""
+		case isSymbol(tok, "("):
+			depth++
+		case isSymbol(tok, ")"):
+			depth--
+		case tok.kind == tokenPlaceholder:
+			return errNotStructured, ""
+		}
+		if depth == 0 {
+			return nil, p.sql[p.tokens[start].start:tok.end]
+		}
+	}
+}
+
+func (p *sqlParser) parseIn(column string, not bool) (error, IExpr) {
+	next := p.peekAt(1)
+	if isKeyword(next, "SELECT") || isKeyword(next, "WITH") {
+		err, sub := p.parseSubquery()
+		if err != nil {
+			return err, nil
+		}
+		if not {
+			return nil, NotInQuery(column, sub)
+		}
+		return nil, InQuery(column, sub)
+	}
+
+	if err := p.expectSymbol("("); err != nil {
+		return err, nil
+	}
+	values := make([]interface{}, 0)
+	for {
+		err, value := p.parseValue()
+		if err != nil {
+			return err, nil
+		}
+		values = append(values, value)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return err, nil
+	}
+
+	if not {
+		return nil, NotIn(column, values...)
+	}
+	return nil, In(column, values...)
+}
+
+// 值: 占位符、字符串、数字、TRUE/FALSE、NULL 或标量子查询
+func (p *sqlParser) parseValue() (error, interface{}) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenPlaceholder:
+		p.advance()
+		return p.lookupArg(tok)
+
+	case tok.kind == tokenString:
+		p.advance()
+		return nil, tok.text
+
+	case tok.kind == tokenNumber, isSymbol(tok, "-") && p.peekAt(1).kind == tokenNumber:
+		text := tok.text
+		if tok.kind == tokenSymbol {
+			p.advance()
+			text += p.peek().text
+		}
+		p.advance()
+		if n, err := strconv.Atoi(text); err == nil {
+			return nil, n
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return p.errorf("invalid number %q", text), nil
+		}
+		return nil, f
+
+	case isKeyword(tok, "TRUE"), isKeyword(tok, "FALSE"):
+		p.advance()
+		return nil, BoolLiteral(isKeyword(tok, "TRUE"))
+
+	case isKeyword(tok, "NULL"):
+		p.advance()
+		return nil, nil
+
+	case isSymbol(tok, "(") && (isKeyword(p.peekAt(1), "SELECT") || isKeyword(p.peekAt(1), "WITH")):
+		return p.parseSubquery()
+	}
+	return errNotStructured, nil
+}
+
+// 收集到条件结束位置的记号作为 RawExpr, 占位符统一替换为 ?
+func (p *sqlParser) parseRaw() (error, IExpr) {
+	start, depth := p.pos, 0
+	b := strings.Builder{}
+	args := make([]interface{}, 0)
+	for {
+		tok := p.peek()
+		if depth == 0 && isExprEnd(tok) {
+			break
+		}
+		if tok.kind == tokenEOF {
+			return p.errorf("unbalanced parentheses"), nil
+		}
+		if isSymbol(tok, "(") {
+			depth++
+		} else if isSymbol(tok, ")") {
+			depth--
+		}
+
+		if p.pos > start {
+			b.WriteString(p.sql[p.tokens[p.pos-1].end:tok.start])
+		}
+		if tok.kind == tokenPlaceholder {
+			err, arg := p.lookupArg(tok)
+			if err != nil {
+				return err, nil
+			}
+			args = append(args, arg)
+			b.WriteString("?")
+		} else {
+			b.WriteString(p.sql[tok.start:tok.end])
+		}
+		p.advance()
+	}
+
+	if p.pos == start {
+		return p.errorf("expecting condition"), nil
+	}
+	return nil, Raw(b.String(), args...)
+}
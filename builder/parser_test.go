@@ -0,0 +1,312 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func Test_Parse(t *testing.T) {
+	err, b := Parse("SELECT id,name FROM user_info u LEFT JOIN orders o ON o.user_id = u.id"+
+		" WHERE (org_id = ?) AND u.age BETWEEN ? AND ? AND (u.name LIKE 'a%' OR u.email IS NULL)"+
+		" GROUP BY u.id HAVING count(*) > ? ORDER BY u.id DESC,name LIMIT 10 OFFSET 20",
+		11, 18, 30, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, mustBuild(t, b), PostgreSQLDialect,
+		`SELECT "id","name" FROM "user_info" "u" LEFT JOIN "orders" "o" ON o.user_id = u.id`+
+			` WHERE "org_id" = $1 AND "u"."age" BETWEEN $2 AND $3 AND ("u"."name" LIKE $4 OR "u"."email" IS NULL)`+
+			` GROUP BY "u"."id" HAVING count(*) > $5 ORDER BY "u"."id" DESC,name LIMIT 10 OFFSET 20`,
+		11, 18, 30, "a%", 2)
+
+	// 解析后可以继续追加条件
+	err, b = ParseDialect(MySQLDialect, "SELECT `id` FROM `user_info` WHERE `org_id` = ? LIMIT 18446744073709551615 OFFSET 5", 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := mustBuild(t, b.WhereExpr(In("status", 1, 2)))
+	expectSQL(t, query, MySQLDialect,
+		"SELECT `id` FROM `user_info` WHERE `org_id` = ? AND `status` IN (?,?) LIMIT 18446744073709551615 OFFSET 5",
+		11, 1, 2)
+
+	// 带方向的表达式排序项保留原文
+	err, b = Parse("SELECT org_id,count(*) FROM user_info GROUP BY org_id ORDER BY count(*) DESC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, mustBuild(t, b), PostgreSQLDialect,
+		`SELECT "org_id",count(*) FROM "user_info" GROUP BY "org_id" ORDER BY count(*) DESC`)
+}
+
+func Test_ParseSubquery(t *testing.T) {
+	sql := `WITH "paid" AS (SELECT "user_id" FROM "orders" WHERE "status" = $1)` +
+		` SELECT "id" FROM "user_info" WHERE "id" IN (SELECT "user_id" FROM "paid") AND NOT EXISTS (SELECT 1 FROM "blacklist" WHERE "org_id" = $2)` +
+		` UNION ALL (SELECT "id" FROM "staff" ORDER BY "id" ASC LIMIT 3) EXCEPT SELECT "id" FROM "robot" ORDER BY "id" DESC`
+	err, b := ParseDialect(PostgreSQLDialect, sql, "paid", 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, mustBuild(t, b), PostgreSQLDialect, sql, "paid", 11)
+
+	err, b = Parse("SELECT p.user_id FROM (SELECT user_id FROM orders WHERE status = ?) p WHERE p.user_id NOT IN (?, ?)", "paid", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, mustBuild(t, b), GenericDialect,
+		"SELECT p.user_id FROM (SELECT user_id FROM orders WHERE status = ?) p WHERE p.user_id NOT IN (?,?)",
+		"paid", 1, 2)
+}
+
+func Test_ParseRoundTrip(t *testing.T) {
+	paid := mustBuild(t, newSQLQueryBuilder().WithTable("orders").WithField("user_id").WhereExpr(Eq("status", "paid")))
+	queries := []ISQLQuery{
+		mustBuild(t, newSQLQueryBuilder().WithTable("user_info").WithField("id").WithField("name AS n").
+			WhereExpr(Or(Eq("org_id", 11), And(Ge("age", 18), Not(IsNull("email"))))).
+			WhereExpr(NotBetween("score", 1.5, -3)).
+			WhereExpr(InQuery("id", paid)).
+			WhereExpr(Ne("active", BoolLiteral(true))).
+			WithOrder("id", Asc).WithLimit(5)),
+		mustBuild(t, newSQLQueryBuilder().WithTableQuery(paid, "p").WithField("count(*) total").
+			WithJoin("users u", "u.id = p.user_id AND u.org_id = ?", 3).
+			WhereExpr(Raw("u.age > u.min_age + ?", 1)).
+			WithGroupBy("u.org_id").WithHavingExpr(Gt("count(*)", 2)).
+			WithOffset(10)),
+		mustBuild(t, newSQLQueryBuilder().WithTable("user_info").WithField("org_id").WithField("count(*)").
+			WithGroupBy("org_id").WithOrderBy("count(*) DESC").WithOrder("org_id", Asc)),
+	}
+
+	dialects := []IDialect{GenericDialect, MySQLDialect, PostgreSQLDialect}
+	for _, dialect := range dialects {
+		for _, query := range queries {
+			sql, args := query.Render(dialect)
+			err, b := ParseDialect(dialect, sql, args...)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", dialect.Name(), sql, err)
+			}
+			// 列与列比较、表达式等无法结构化的条件保留为 RawExpr 并在组合时加括号, 结构与原查询不同,
+			// 因此这里比较的是再次解析后的输出; 可以结构化还原的写法见 Test_ParseRoundTripGenerated
+			parsed, parsedArgs := mustBuild(t, b).Render(dialect)
+			if !reflect.DeepEqual(parsedArgs, args) {
+				t.Fatalf("%s: expecting args %v, got %v", dialect.Name(), args, parsedArgs)
+			}
+			err, b = ParseDialect(dialect, parsed, parsedArgs...)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", dialect.Name(), parsed, err)
+			}
+			actual, actualArgs := mustBuild(t, b).Render(dialect)
+			if actual != parsed || !reflect.DeepEqual(actualArgs, args) {
+				t.Fatalf("%s: expecting %s %v, got %s %v", dialect.Name(), parsed, args, actual, actualArgs)
+			}
+		}
+	}
+}
+
+// 随机生成的查询, 只包含解析后能还原为相同结构的写法:
+//   - 多个 WHERE/HAVING 条件分别传入, 不以顶层的 And() 组合, 顶层的 AND 在解析时会拆分为多个条件
+//   - And()/Or() 至少包含两个子表达式, 且不直接嵌套同类的组合; 单个子表达式的组合渲染时不输出运算符,
+//     And(And(a, b), c) 与 And(a, b, c) 的输出相同, 解析后均得到扁平的结构
+//   - 不包含 Raw() 与字符串形式的条件, 二者解析后为语义相同的结构化表达式或括号中的 RawExpr
+type generatedQuery struct {
+	query *SQLQuery
+}
+
+var (
+	generatedColumns = []string{"id", "name", "age", "u.org_id", "u.email"}
+	generatedTables  = []string{"user_info", "user_info u", "app.user_info"}
+)
+
+func (generatedQuery) Generate(r *rand.Rand, size int) reflect.Value {
+	pick := func(items []string) string {
+		return items[r.Intn(len(items))]
+	}
+	value := func() interface{} {
+		if r.Intn(2) == 0 {
+			return r.Intn(1000) - 500
+		}
+		return fmt.Sprintf("v%d", r.Intn(1000))
+	}
+
+	var expr func(depth int, parent string) IExpr
+	expr = func(depth int, parent string) IExpr {
+		kind := r.Intn(10)
+		if depth <= 0 {
+			kind = r.Intn(7)
+		}
+		column := pick(generatedColumns)
+		switch kind {
+		case 0:
+			ops := []func(string, interface{}) IExpr{Eq, Ne, Gt, Ge, Lt, Le}
+			return ops[r.Intn(len(ops))](column, value())
+		case 1:
+			values := make([]interface{}, r.Intn(3)+1)
+			for i := range values {
+				values[i] = value()
+			}
+			if r.Intn(2) == 0 {
+				return In(column, values...)
+			}
+			return NotIn(column, values...)
+		case 2:
+			if r.Intn(2) == 0 {
+				return Between(column, value(), value())
+			}
+			return NotBetween(column, value(), value())
+		case 3:
+			if r.Intn(2) == 0 {
+				return Like(column, fmt.Sprintf("%%%d%%", r.Intn(100)))
+			}
+			return NotLike(column, fmt.Sprintf("%d%%", r.Intn(100)))
+		case 4:
+			return IsNull(column)
+		case 5:
+			return IsNotNull(column)
+		case 6:
+			return Eq(column, value())
+		case 7:
+			return Not(expr(depth-1, "NOT"))
+		}
+
+		op := "AND"
+		if kind == 9 || parent == "AND" {
+			op = "OR"
+		}
+		if parent == "OR" {
+			op = "AND"
+		}
+		items := make([]IExpr, r.Intn(2)+2)
+		for i := range items {
+			items[i] = expr(depth-1, op)
+		}
+		if op == "AND" {
+			return And(items...)
+		}
+		return Or(items...)
+	}
+
+	b := newSQLQueryBuilder().WithTable(pick(generatedTables))
+	if r.Intn(3) == 0 {
+		joins := []JoinType{InnerJoin, LeftJoin, RightJoin}
+		b.WithJoinExpr(joins[r.Intn(len(joins))], "orders o", expr(1, "AND"))
+	}
+	for i := r.Intn(3); i >= 0; i-- {
+		b.WithField(pick(generatedColumns))
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		b.WhereExpr(expr(2, "AND"))
+	}
+	if r.Intn(3) == 0 {
+		b.WithGroupBy(pick(generatedColumns))
+		if r.Intn(2) == 0 {
+			b.WithHavingExpr(expr(1, "AND"))
+		}
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		direction := Asc
+		if r.Intn(2) == 0 {
+			direction = Desc
+		}
+		b.WithOrder(pick(generatedColumns), direction)
+	}
+	if r.Intn(2) == 0 {
+		b.WithLimit(r.Intn(100))
+	}
+	if r.Intn(3) == 0 {
+		b.WithOffset(r.Intn(100))
+	}
+
+	err, query := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return reflect.ValueOf(generatedQuery{query: query.(*SQLQuery)})
+}
+
+// Parse(Render(q)) 还原出与 q 结构相同的查询
+func Test_ParseRoundTripGenerated(t *testing.T) {
+	for _, dialect := range []IDialect{GenericDialect, MySQLDialect, PostgreSQLDialect, SQLiteDialect} {
+		roundTrip := func(generated generatedQuery) bool {
+			expected := generated.query.clone()
+			expected.dialect = dialect
+
+			sql, args := expected.Render(dialect)
+			err, b := ParseDialect(dialect, sql, args...)
+			if err != nil {
+				t.Logf("%s: %s: %v", dialect.Name(), sql, err)
+				return false
+			}
+			err, parsed := b.Build()
+			if err != nil {
+				t.Logf("%s: %s: %v", dialect.Name(), sql, err)
+				return false
+			}
+			if !reflect.DeepEqual(parsed, expected) {
+				actual, _ := parsed.Render(dialect)
+				t.Logf("%s: expecting %s\n%#v\ngot %s\n%#v", dialect.Name(), sql, expected, actual, parsed)
+				return false
+			}
+			return true
+		}
+		if err := quick.Check(roundTrip, &quick.Config{MaxCount: 300}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// 解析后结构发生变化的写法, 渲染出的 SQL 与原查询相同
+func Test_ParseRoundTripNormalized(t *testing.T) {
+	a, b, c := Eq("a", 1), Gt("b", 2), IsNull("c")
+	cases := []struct {
+		conditions []IExpr
+		expected   []IExpr
+	}{
+		// 顶层的 AND 拆分为多个条件
+		{[]IExpr{And(a, b)}, []IExpr{a, b}},
+		// 单个子表达式的组合还原为子表达式本身
+		{[]IExpr{Or(a)}, []IExpr{a}},
+		// 同类的嵌套组合被展开
+		{[]IExpr{Or(Or(a, b), c)}, []IExpr{Or(a, b, c)}},
+		// 字符串形式的条件在能够识别时还原为结构化表达式
+		{[]IExpr{Raw("a = ?", 1)}, []IExpr{a}},
+	}
+	for _, it := range cases {
+		query := newSQLQueryBuilder().WithTable("t")
+		for _, condition := range it.conditions {
+			query.WhereExpr(condition)
+		}
+		sql, args := mustBuild(t, query).Render(GenericDialect)
+		err, parsed := Parse(sql, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := mustBuild(t, parsed).(*SQLQuery).conditions
+		if !reflect.DeepEqual(actual, it.expected) {
+			t.Fatalf("%s: expecting %#v, got %#v", sql, it.expected, actual)
+		}
+	}
+}
+
+func Test_ParseError(t *testing.T) {
+	cases := []struct {
+		sql  string
+		args []interface{}
+		err  error
+	}{
+		{"SELECT id", nil, ErrSyntax},
+		{"SELECT id FROM user_info WHERE name = 'abc", nil, ErrSyntax},
+		{"SELECT id FROM user_info WHERE (id = ?", []interface{}{1}, ErrSyntax},
+		{"SELECT id FROM user_info LIMIT ?", []interface{}{1}, ErrSyntax},
+		{"SELECT id FROM user_info WHERE id = ?", nil, ErrArgCount},
+		{"SELECT id FROM user_info WHERE id = ?", []interface{}{1, 2}, ErrArgCount},
+		{"DELETE FROM user_info", nil, ErrSyntax},
+	}
+	for _, it := range cases {
+		err, _ := Parse(it.sql, it.args...)
+		if !errors.Is(err, it.err) {
+			t.Fatalf("%s: expecting %v, got %v", it.sql, it.err, err)
+		}
+	}
+}
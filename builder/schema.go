@@ -0,0 +1,434 @@
+package builder
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownColumn   = errors.New("unknown column")
+	ErrTypeMismatch    = errors.New("value type does not match column type")
+	ErrDuplicateColumn = errors.New("duplicate column")
+)
+
+// 列的类型, 构建查询时用于检查条件中的值
+type ColumnType int
+
+const (
+	TypeAny ColumnType = iota // 不检查值的类型
+	TypeInt
+	TypeFloat
+	TypeString
+	TypeBool
+	TypeTime
+	TypeBytes
+)
+
+func (c ColumnType) String() string {
+	switch c {
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeString:
+		return "string"
+	case TypeBool:
+		return "bool"
+	case TypeTime:
+		return "time"
+	case TypeBytes:
+		return "bytes"
+	}
+	return "any"
+}
+
+// 列的定义, 同时作为类型化的列引用提供比较运算, 生成的条件与 Eq()/In() 等函数相同
+type Column struct {
+	Name     string
+	Type     ColumnType
+	Nullable bool
+}
+
+// 可为空的列与 nil 比较时生成 IS NULL, 不可为空的列在 Build() 时报告类型不符
+func (c *Column) Eq(value interface{}) IExpr {
+	if c.Nullable && isNilValue(value) {
+		return c.IsNull()
+	}
+	return Eq(c.Name, value)
+}
+
+// 可为空的列与 nil 比较时生成 IS NOT NULL
+func (c *Column) Ne(value interface{}) IExpr {
+	if c.Nullable && isNilValue(value) {
+		return c.IsNotNull()
+	}
+	return Ne(c.Name, value)
+}
+
+func (c *Column) Gt(value interface{}) IExpr {
+	return Gt(c.Name, value)
+}
+
+func (c *Column) Ge(value interface{}) IExpr {
+	return Ge(c.Name, value)
+}
+
+func (c *Column) Lt(value interface{}) IExpr {
+	return Lt(c.Name, value)
+}
+
+func (c *Column) Le(value interface{}) IExpr {
+	return Le(c.Name, value)
+}
+
+func (c *Column) In(values ...interface{}) IExpr {
+	return In(c.Name, values...)
+}
+
+func (c *Column) NotIn(values ...interface{}) IExpr {
+	return NotIn(c.Name, values...)
+}
+
+func (c *Column) Between(low interface{}, high interface{}) IExpr {
+	return Between(c.Name, low, high)
+}
+
+func (c *Column) Like(pattern string) IExpr {
+	return Like(c.Name, pattern)
+}
+
+func (c *Column) IsNull() IExpr {
+	return IsNull(c.Name)
+}
+
+func (c *Column) IsNotNull() IExpr {
+	return IsNotNull(c.Name)
+}
+
+// 检查值能否与该列比较, 与 NULL 的比较结果恒为未知, 因此不接受 nil, 需使用 IsNull()/IsNotNull()
+// sql.Null* 按其对应的列类型检查, 子查询与其它 driver.Valuer 不做检查
+func (c *Column) accepts(value interface{}) bool {
+	if isNilValue(value) {
+		return false
+	}
+
+	t := reflect.TypeOf(value)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if nullType, ok := nullTypes[t]; ok {
+		return c.matches(nullType)
+	}
+
+	switch value.(type) {
+	case ISQLQuery, driver.Valuer:
+		return true
+	case BoolLiteral:
+		return c.Type == TypeAny || c.Type == TypeBool
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		return c.accepts(v.Elem().Interface())
+	}
+	return c.matches(typeOf(v.Type()))
+}
+
+// 整数可以与浮点数列比较
+func (c *Column) matches(t ColumnType) bool {
+	return c.Type == TypeAny || c.Type == t || (c.Type == TypeFloat && t == TypeInt)
+}
+
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// sql.Null* 类型对应的列类型
+var nullTypes = map[reflect.Type]ColumnType{
+	reflect.TypeOf(sql.NullString{}):  TypeString,
+	reflect.TypeOf(sql.NullInt64{}):   TypeInt,
+	reflect.TypeOf(sql.NullInt32{}):   TypeInt,
+	reflect.TypeOf(sql.NullFloat64{}): TypeFloat,
+	reflect.TypeOf(sql.NullBool{}):    TypeBool,
+	reflect.TypeOf(sql.NullTime{}):    TypeTime,
+}
+
+func typeOf(t reflect.Type) ColumnType {
+	switch {
+	case t == timeType:
+		return TypeTime
+	case t == bytesType:
+		return TypeBytes
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeInt
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat
+	case reflect.String:
+		return TypeString
+	case reflect.Bool:
+		return TypeBool
+	}
+	return TypeAny
+}
+
+// 表结构, 描述表名与各列的类型
+type Table struct {
+	name      string
+	qualifier string // 列的限定前缀, 表带别名时为别名, 否则为表名
+	columns   []*Column
+	index     map[string]*Column
+}
+
+func NewTable(name string, columns ...Column) (error, *Table) {
+	if len(name) == 0 {
+		return ErrEmptyTable, nil
+	}
+	if !isTableName(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name), nil
+	}
+
+	parts := strings.Fields(name)
+	t := &Table{
+		name:      name,
+		qualifier: parts[len(parts)-1],
+		columns:   make([]*Column, 0, len(columns)),
+		index:     make(map[string]*Column),
+	}
+	for _, it := range columns {
+		if !identPattern.MatchString(it.Name) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, it.Name), nil
+		}
+		if _, ok := t.index[it.Name]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateColumn, it.Name), nil
+		}
+		column := it
+		t.columns = append(t.columns, &column)
+		t.index[it.Name] = &column
+	}
+	return nil, t
+}
+
+// 由结构体推导表结构, 列名的规则与 Query() 映射结果时相同
+// 指针与 sql.Null* 类型的字段为可为空的列
+func TableOf(name string, model interface{}) (error, *Table) {
+	t := reflect.TypeOf(model)
+	if t == nil || !isStructType(t) {
+		return ErrInvalidDest, nil
+	}
+	t = derefType(t)
+
+	// 按字段的声明顺序排列, 嵌入结构体的字段位于嵌入位置
+	mapping := structColumns(t)
+	names := make([]string, 0, len(mapping))
+	for name := range mapping {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return lessIndex(mapping[names[i]], mapping[names[j]])
+	})
+
+	columns := make([]Column, 0, len(names))
+	for _, it := range names {
+		fieldType := t.FieldByIndex(mapping[it]).Type
+		column := Column{Name: it}
+		if fieldType.Kind() == reflect.Ptr {
+			column.Nullable = true
+			fieldType = fieldType.Elem()
+		}
+		if nullType, ok := nullTypes[fieldType]; ok {
+			column.Nullable = true
+			column.Type = nullType
+		} else {
+			column.Type = typeOf(fieldType)
+		}
+		columns = append(columns, column)
+	}
+	return NewTable(name, columns...)
+}
+
+func lessIndex(a []int, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func (t *Table) Name() string {
+	return t.name
+}
+
+func (t *Table) Columns() []*Column {
+	return append([]*Column{}, t.columns...)
+}
+
+// 按名称引用列, 列不存在时返回的引用在构建查询时报告 ErrUnknownColumn
+func (t *Table) C(name string) *Column {
+	if it, ok := t.index[name]; ok {
+		return it
+	}
+	return &Column{Name: name}
+}
+
+// 查询该表, 未指定列时查询全部列
+func (t *Table) Select(columns ...*Column) ITypedQueryBuilder {
+	if len(columns) == 0 {
+		columns = t.columns
+	}
+
+	builder := newSQLQueryBuilder().WithTable(t.name)
+	for _, it := range columns {
+		builder.WithField(it.Name)
+	}
+	return &TypedQueryBuilder{
+		table:   t,
+		builder: builder,
+		columns: append([]*Column{}, columns...),
+	}
+}
+
+// 查询中引用的列, 可以带表名前缀; 表带别名时以别名作为前缀, 例如 "product p" 的 p.price
+func (t *Table) lookup(name string) (*Column, bool) {
+	if it, ok := t.index[name]; ok {
+		return it, true
+	}
+	prefix := t.qualifier + "."
+	if len(name) > len(prefix) && name[:len(prefix)] == prefix {
+		it, ok := t.index[name[len(prefix):]]
+		return it, ok
+	}
+	return nil, false
+}
+
+// 类型化的查询建造者, 字段、条件、分组与排序只能引用表结构中的列
+// 未知的列以及与列类型不符的值在 Build() 时报告错误
+type ITypedQueryBuilder interface {
+	Where(expr IExpr) ITypedQueryBuilder
+	GroupBy(columns ...*Column) ITypedQueryBuilder
+	Having(expr IExpr) ITypedQueryBuilder
+	OrderBy(column *Column, direction SortDirection) ITypedQueryBuilder
+	WithLimit(limit int) ITypedQueryBuilder
+	WithOffset(offset int) ITypedQueryBuilder
+	WithDialect(dialect IDialect) ITypedQueryBuilder
+	Build() (error, ISQLQuery)
+}
+
+type TypedQueryBuilder struct {
+	table      *Table
+	builder    ISQLQueryBuilder
+	columns    []*Column // 字段、分组与排序引用的列
+	conditions []IExpr
+}
+
+func (s *TypedQueryBuilder) Where(expr IExpr) ITypedQueryBuilder {
+	s.builder.WhereExpr(expr)
+	s.conditions = append(s.conditions, expr)
+	return s
+}
+
+func (s *TypedQueryBuilder) GroupBy(columns ...*Column) ITypedQueryBuilder {
+	for _, it := range columns {
+		s.builder.WithGroupBy(it.Name)
+	}
+	s.columns = append(s.columns, columns...)
+	return s
+}
+
+func (s *TypedQueryBuilder) Having(expr IExpr) ITypedQueryBuilder {
+	s.builder.WithHavingExpr(expr)
+	s.conditions = append(s.conditions, expr)
+	return s
+}
+
+func (s *TypedQueryBuilder) OrderBy(column *Column, direction SortDirection) ITypedQueryBuilder {
+	s.builder.WithOrder(column.Name, direction)
+	s.columns = append(s.columns, column)
+	return s
+}
+
+func (s *TypedQueryBuilder) WithLimit(limit int) ITypedQueryBuilder {
+	s.builder.WithLimit(limit)
+	return s
+}
+
+func (s *TypedQueryBuilder) WithOffset(offset int) ITypedQueryBuilder {
+	s.builder.WithOffset(offset)
+	return s
+}
+
+func (s *TypedQueryBuilder) WithDialect(dialect IDialect) ITypedQueryBuilder {
+	s.builder.WithDialect(dialect)
+	return s
+}
+
+func (s *TypedQueryBuilder) Build() (error, ISQLQuery) {
+	for _, it := range s.columns {
+		if _, ok := s.table.lookup(it.Name); !ok {
+			return fmt.Errorf("%w: %s.%s", ErrUnknownColumn, s.table.qualifier, it.Name), nil
+		}
+	}
+	for _, it := range s.conditions {
+		if err := s.check(it); err != nil {
+			return err, nil
+		}
+	}
+
+	return s.builder.Build()
+}
+
+// 检查条件中引用的列与值, RawExpr 与函数调用等无法识别的列不做检查
+func (s *TypedQueryBuilder) check(expr IExpr) error {
+	var err error
+	Walk(expr, func(expr IExpr) bool {
+		if err != nil {
+			return false
+		}
+		switch it := expr.(type) {
+		case *CompareExpr:
+			err = s.checkValues(it.Column, it.Value)
+		case *InExpr:
+			err = s.checkValues(it.Column, it.Values...)
+		case *BetweenExpr:
+			err = s.checkValues(it.Column, it.Low, it.High)
+		case *LikeExpr:
+			err = s.checkValues(it.Column, it.Pattern)
+		case *IsNullExpr:
+			err = s.checkValues(it.Column)
+		}
+		return true
+	})
+	return err
+}
+
+func (s *TypedQueryBuilder) checkValues(name string, values ...interface{}) error {
+	column, ok := s.table.lookup(name)
+	if !ok {
+		return fmt.Errorf("%w: %s.%s", ErrUnknownColumn, s.table.qualifier, name)
+	}
+	for _, it := range values {
+		if !column.accepts(it) {
+			return fmt.Errorf("%w: %s is %s, got %T", ErrTypeMismatch, name, column.Type, it)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package builder
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type productRow struct {
+	ID        int64
+	Name      string
+	Price     float64
+	Enable    bool
+	Remark    sql.NullString
+	Stock     *int `db:"inventory"`
+	CreatedAt time.Time
+	Ignored   string `db:"-"`
+}
+
+func Test_TableOf(t *testing.T) {
+	err, product := TableOf("product", productRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Column{
+		{Name: "id", Type: TypeInt},
+		{Name: "name", Type: TypeString},
+		{Name: "price", Type: TypeFloat},
+		{Name: "enable", Type: TypeBool},
+		{Name: "remark", Type: TypeString, Nullable: true},
+		{Name: "inventory", Type: TypeInt, Nullable: true},
+		{Name: "created_at", Type: TypeTime},
+	}
+	actual := make([]Column, 0)
+	for _, it := range product.Columns() {
+		actual = append(actual, *it)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expecting %v, got %v", expected, actual)
+	}
+
+	if err, _ := TableOf("product", 1); !errors.Is(err, ErrInvalidDest) {
+		t.Fatalf("expecting ErrInvalidDest, got %v", err)
+	}
+	if err, _ := NewTable("product", Column{Name: "id"}, Column{Name: "id"}); !errors.Is(err, ErrDuplicateColumn) {
+		t.Fatalf("expecting ErrDuplicateColumn, got %v", err)
+	}
+}
+
+func Test_TypedQuery(t *testing.T) {
+	err, product := NewTable("product",
+		Column{Name: "id", Type: TypeInt},
+		Column{Name: "name", Type: TypeString},
+		Column{Name: "price", Type: TypeFloat},
+		Column{Name: "remark", Type: TypeString, Nullable: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, name, price := product.C("id"), product.C("name"), product.C("price")
+
+	err, query := product.Select(id, name).
+		Where(Or(price.Between(10, 99.5), name.Like("tv%"))).
+		Where(id.In(1, 2, 3)).
+		Where(product.C("remark").Eq(nil)).
+		OrderBy(price, Desc).
+		WithDialect(PostgreSQLDialect).
+		WithLimit(10).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, query, PostgreSQLDialect,
+		`SELECT "id","name" FROM "product" WHERE ("price" BETWEEN $1 AND $2 OR "name" LIKE $3) AND "id" IN ($4,$5,$6)`+
+			` AND "remark" IS NULL ORDER BY "price" DESC LIMIT 10`,
+		10, 99.5, "tv%", 1, 2, 3)
+
+	err, query = product.Select().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, query, GenericDialect, "SELECT id,name,price,remark FROM product")
+
+	cases := []struct {
+		builder ITypedQueryBuilder
+		err     error
+	}{
+		{product.Select(product.C("nmae")), ErrUnknownColumn},
+		{product.Select().Where(Eq("product.nmae", "tv")), ErrUnknownColumn},
+		{product.Select().OrderBy(product.C("cost"), Asc), ErrUnknownColumn},
+		{product.Select().Where(id.Eq("1")), ErrTypeMismatch},
+		{product.Select().Where(Not(name.In("tv", 3))), ErrTypeMismatch},
+		{product.Select().Where(price.Gt(true)), ErrTypeMismatch},
+		{product.Select().Where(name.Eq(nil)), ErrTypeMismatch},
+		{product.Select().Where(Eq("remark", nil)), ErrTypeMismatch},
+		{product.Select().Where(product.C("remark").In("a", nil)), ErrTypeMismatch},
+		{product.Select().Where(id.Like("1%")), ErrTypeMismatch},
+		{product.Select().Where(id.Eq(sql.NullString{String: "1", Valid: true})), ErrTypeMismatch},
+		{product.Select().Where(name.In(&sql.NullInt64{Int64: 1, Valid: true})), ErrTypeMismatch},
+	}
+	for i, it := range cases {
+		err, query := it.builder.Build()
+		if !errors.Is(err, it.err) || query != nil {
+			t.Fatalf("case %d: expecting %v, got %v", i, it.err, err)
+		}
+	}
+
+	// 可为空的列与 nil(包括 nil 指针)比较时生成 IS NULL/IS NOT NULL
+	var remark *string
+	err, query = product.Select(id).Where(product.C("remark").Ne(remark)).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, query, GenericDialect, "SELECT id FROM product WHERE remark IS NOT NULL")
+
+	// sql.Null* 按其对应的列类型检查
+	err, query = product.Select().
+		Where(name.Eq(sql.NullString{String: "tv", Valid: true})).
+		Where(price.Gt(&sql.NullInt64{Int64: 10, Valid: true})).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, query, GenericDialect, "SELECT id,name,price,remark FROM product WHERE name = ? AND price > ?",
+		sql.NullString{String: "tv", Valid: true}, &sql.NullInt64{Int64: 10, Valid: true})
+}
+
+func Test_TypedQueryAlias(t *testing.T) {
+	err, product := NewTable("product p", Column{Name: "id", Type: TypeInt}, Column{Name: "price", Type: TypeFloat})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 带别名的表以别名限定列
+	err, query := product.Select(product.C("id")).Where(Gt("p.price", 10)).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSQL(t, query, PostgreSQLDialect, `SELECT "id" FROM "product" "p" WHERE "p"."price" > $1`, 10)
+
+	if err, _ := product.Select().Where(Gt("p.price", "10")).Build(); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expecting ErrTypeMismatch, got %v", err)
+	}
+	if err, _ := product.Select().Where(Gt("product.price", 10)).Build(); !errors.Is(err, ErrUnknownColumn) {
+		t.Fatalf("expecting ErrUnknownColumn, got %v", err)
+	}
+}
@@ -0,0 +1,562 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrEmptyTable     = errors.New("table is empty")
+	ErrEmptyColumns   = errors.New("no columns to write")
+	ErrValueCount     = errors.New("value count does not match column count")
+	ErrMissingWhere   = errors.New("missing WHERE condition, call AllowWithoutWhere() to affect all rows")
+	ErrEmptyCondition = errors.New("condition is empty")
+)
+
+// INSERT 语句的建造者接口
+type ISQLInsertBuilder interface {
+	WithTable(table string) ISQLInsertBuilder
+	WithColumns(columns ...string) ISQLInsertBuilder
+	// 追加一行值, 多次调用即为多行插入
+	WithValues(values ...interface{}) ISQLInsertBuilder
+	// MySQL: ON DUPLICATE KEY UPDATE col=VALUES(col)
+	OnDuplicateKeyUpdate(columns ...string) ISQLInsertBuilder
+	// PostgreSQL/SQLite: ON CONFLICT (conflict) DO UPDATE SET col=EXCLUDED.col
+	OnConflictUpdate(conflict []string, columns ...string) ISQLInsertBuilder
+	// PostgreSQL/SQLite: ON CONFLICT (conflict) DO NOTHING
+	OnConflictDoNothing(conflict ...string) ISQLInsertBuilder
+	WithDialect(dialect IDialect) ISQLInsertBuilder
+	Build() (error, ISQLQuery)
+}
+
+// UPDATE 语句的建造者接口
+type ISQLUpdateBuilder interface {
+	WithTable(table string) ISQLUpdateBuilder
+	WithSet(column string, value interface{}) ISQLUpdateBuilder
+	// 以表达式赋值, 例如 WithSetExpr("stock", "stock - ?", 1)
+	WithSetExpr(column string, expr string, args ...interface{}) ISQLUpdateBuilder
+	WithCondition(condition string) ISQLUpdateBuilder
+	Where(condition string, args ...interface{}) ISQLUpdateBuilder
+	WhereExpr(expr IExpr) ISQLUpdateBuilder
+	// 允许不带 WHERE 条件更新全表
+	AllowWithoutWhere() ISQLUpdateBuilder
+	WithDialect(dialect IDialect) ISQLUpdateBuilder
+	Build() (error, ISQLQuery)
+}
+
+// DELETE 语句的建造者接口
+type ISQLDeleteBuilder interface {
+	WithTable(table string) ISQLDeleteBuilder
+	WithCondition(condition string) ISQLDeleteBuilder
+	Where(condition string, args ...interface{}) ISQLDeleteBuilder
+	WhereExpr(expr IExpr) ISQLDeleteBuilder
+	// 允许不带 WHERE 条件删除全表
+	AllowWithoutWhere() ISQLDeleteBuilder
+	WithDialect(dialect IDialect) ISQLDeleteBuilder
+	Build() (error, ISQLQuery)
+}
+
+// 插入冲突时的处理方式
+type conflictAction int
+
+const (
+	conflictNone conflictAction = iota
+	conflictDuplicateKeyUpdate
+	conflictUpdate
+	conflictDoNothing
+)
+
+// 实现 ISQLQuery 接口
+type SQLInsert struct {
+	table         string
+	columns       []string
+	rows          [][]interface{}
+	conflict      conflictAction
+	conflictKeys  []string
+	updateColumns []string
+	dialect       IDialect
+}
+
+func newSQLInsert() *SQLInsert {
+	return &SQLInsert{
+		columns: make([]string, 0),
+		rows:    make([][]interface{}, 0),
+		dialect: GenericDialect,
+	}
+}
+
+func (s *SQLInsert) ToSQL() string {
+	sql, _ := s.Render(s.dialect)
+	return sql
+}
+
+func (s *SQLInsert) Args() []interface{} {
+	_, args := s.Render(s.dialect)
+	return args
+}
+
+func (s *SQLInsert) Render(dialect IDialect) (string, []interface{}) {
+	b := newSQLWriter(dialect)
+
+	b.WriteString("INSERT INTO ")
+	b.writeIdentifier(s.table)
+	b.WriteString(" (")
+	b.writeIdentifiers(s.columns)
+	b.WriteString(") VALUES ")
+
+	for i, row := range s.rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("(")
+		for j, it := range row {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			b.writeArg(it)
+		}
+		b.WriteString(")")
+	}
+
+	switch s.conflict {
+	case conflictDuplicateKeyUpdate:
+		b.WriteString(" ON DUPLICATE KEY UPDATE ")
+		for i, it := range s.updateColumns {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.writeIdentifier(it)
+			b.WriteString("=VALUES(")
+			b.writeIdentifier(it)
+			b.WriteString(")")
+		}
+	case conflictUpdate:
+		b.WriteString(" ON CONFLICT (")
+		b.writeIdentifiers(s.conflictKeys)
+		b.WriteString(") DO UPDATE SET ")
+		for i, it := range s.updateColumns {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.writeIdentifier(it)
+			b.WriteString("=EXCLUDED.")
+			b.writeIdentifier(it)
+		}
+	case conflictDoNothing:
+		b.WriteString(" ON CONFLICT")
+		if len(s.conflictKeys) > 0 {
+			b.WriteString(" (")
+			b.writeIdentifiers(s.conflictKeys)
+			b.WriteString(")")
+		}
+		b.WriteString(" DO NOTHING")
+	}
+
+	return b.String(), b.args
+}
+
+type SQLInsertBuilder struct {
+	insert *SQLInsert
+}
+
+func newSQLInsertBuilder() ISQLInsertBuilder {
+	return &SQLInsertBuilder{
+		insert: newSQLInsert(),
+	}
+}
+
+func (s *SQLInsertBuilder) WithTable(table string) ISQLInsertBuilder {
+	s.insert.table = table
+	return s
+}
+
+func (s *SQLInsertBuilder) WithColumns(columns ...string) ISQLInsertBuilder {
+	s.insert.columns = append(s.insert.columns, columns...)
+	return s
+}
+
+func (s *SQLInsertBuilder) WithValues(values ...interface{}) ISQLInsertBuilder {
+	s.insert.rows = append(s.insert.rows, values)
+	return s
+}
+
+func (s *SQLInsertBuilder) OnDuplicateKeyUpdate(columns ...string) ISQLInsertBuilder {
+	s.insert.conflict = conflictDuplicateKeyUpdate
+	s.insert.conflictKeys = nil
+	s.insert.updateColumns = columns
+	return s
+}
+
+func (s *SQLInsertBuilder) OnConflictUpdate(conflict []string, columns ...string) ISQLInsertBuilder {
+	s.insert.conflict = conflictUpdate
+	s.insert.conflictKeys = conflict
+	s.insert.updateColumns = columns
+	return s
+}
+
+func (s *SQLInsertBuilder) OnConflictDoNothing(conflict ...string) ISQLInsertBuilder {
+	s.insert.conflict = conflictDoNothing
+	s.insert.conflictKeys = conflict
+	s.insert.updateColumns = nil
+	return s
+}
+
+func (s *SQLInsertBuilder) WithDialect(dialect IDialect) ISQLInsertBuilder {
+	s.insert.dialect = dialect
+	return s
+}
+
+func (s *SQLInsertBuilder) Build() (error, ISQLQuery) {
+	if err := s.insert.validate(); err != nil {
+		return err, nil
+	}
+	return nil, s.insert.clone()
+}
+
+func (s *SQLInsert) clone() *SQLInsert {
+	query := *s
+	query.columns = append([]string{}, s.columns...)
+	query.rows = make([][]interface{}, len(s.rows))
+	for i, it := range s.rows {
+		query.rows[i] = append([]interface{}{}, it...)
+	}
+	query.conflictKeys = append([]string{}, s.conflictKeys...)
+	query.updateColumns = append([]string{}, s.updateColumns...)
+	return &query
+}
+
+func (s *SQLInsert) validate() error {
+	if err := validateTable(s.table); err != nil {
+		return err
+	}
+	if len(s.columns) == 0 || len(s.rows) == 0 {
+		return ErrEmptyColumns
+	}
+	for _, it := range s.rows {
+		if len(it) != len(s.columns) {
+			return ErrValueCount
+		}
+	}
+	if (s.conflict == conflictDuplicateKeyUpdate || s.conflict == conflictUpdate) && len(s.updateColumns) == 0 {
+		return ErrEmptyColumns
+	}
+	return validateColumns(s.columns, s.conflictKeys, s.updateColumns)
+}
+
+// UPDATE 语句中的一个赋值项
+type sqlAssignment struct {
+	column string
+	value  IExpr
+}
+
+// 实现 ISQLQuery 接口
+type SQLUpdate struct {
+	table             string
+	assignments       []sqlAssignment
+	conditions        []IExpr
+	allowWithoutWhere bool
+	dialect           IDialect
+}
+
+func newSQLUpdate() *SQLUpdate {
+	return &SQLUpdate{
+		assignments: make([]sqlAssignment, 0),
+		conditions:  make([]IExpr, 0),
+		dialect:     GenericDialect,
+	}
+}
+
+func (s *SQLUpdate) ToSQL() string {
+	sql, _ := s.Render(s.dialect)
+	return sql
+}
+
+func (s *SQLUpdate) Args() []interface{} {
+	_, args := s.Render(s.dialect)
+	return args
+}
+
+func (s *SQLUpdate) Render(dialect IDialect) (string, []interface{}) {
+	b := newSQLWriter(dialect)
+
+	b.WriteString("UPDATE ")
+	b.writeIdentifier(s.table)
+	b.WriteString(" SET ")
+	for i, it := range s.assignments {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.writeIdentifier(it.column)
+		b.WriteString("=")
+		it.value.writeTo(b)
+	}
+
+	if len(s.conditions) > 0 {
+		b.WriteString(" WHERE ")
+		b.writeConditions(s.conditions)
+	}
+
+	return b.String(), b.args
+}
+
+type SQLUpdateBuilder struct {
+	update *SQLUpdate
+}
+
+func newSQLUpdateBuilder() ISQLUpdateBuilder {
+	return &SQLUpdateBuilder{
+		update: newSQLUpdate(),
+	}
+}
+
+func (s *SQLUpdateBuilder) WithTable(table string) ISQLUpdateBuilder {
+	s.update.table = table
+	return s
+}
+
+func (s *SQLUpdateBuilder) WithSet(column string, value interface{}) ISQLUpdateBuilder {
+	return s.WithSetExpr(column, "?", value)
+}
+
+func (s *SQLUpdateBuilder) WithSetExpr(column string, expr string, args ...interface{}) ISQLUpdateBuilder {
+	s.update.assignments = append(s.update.assignments, sqlAssignment{
+		column: column,
+		value:  Raw(expr, args...),
+	})
+	return s
+}
+
+func (s *SQLUpdateBuilder) WithCondition(condition string) ISQLUpdateBuilder {
+	return s.Where(condition)
+}
+
+func (s *SQLUpdateBuilder) Where(condition string, args ...interface{}) ISQLUpdateBuilder {
+	return s.WhereExpr(Raw(condition, args...))
+}
+
+func (s *SQLUpdateBuilder) WhereExpr(expr IExpr) ISQLUpdateBuilder {
+	s.update.conditions = append(s.update.conditions, expr)
+	return s
+}
+
+func (s *SQLUpdateBuilder) AllowWithoutWhere() ISQLUpdateBuilder {
+	s.update.allowWithoutWhere = true
+	return s
+}
+
+func (s *SQLUpdateBuilder) WithDialect(dialect IDialect) ISQLUpdateBuilder {
+	s.update.dialect = dialect
+	return s
+}
+
+func (s *SQLUpdateBuilder) Build() (error, ISQLQuery) {
+	if err := s.update.validate(); err != nil {
+		return err, nil
+	}
+	return nil, s.update.clone()
+}
+
+func (s *SQLUpdate) clone() *SQLUpdate {
+	query := *s
+	query.assignments = append([]sqlAssignment{}, s.assignments...)
+	for i, it := range query.assignments {
+		query.assignments[i].value = cloneExpr(it.value)
+	}
+	query.conditions = cloneExprs(s.conditions)
+	return &query
+}
+
+func (s *SQLUpdate) validate() error {
+	if err := validateTable(s.table); err != nil {
+		return err
+	}
+	if len(s.assignments) == 0 {
+		return ErrEmptyColumns
+	}
+	for _, it := range s.assignments {
+		if err := validateColumns([]string{it.column}); err != nil {
+			return err
+		}
+		if err := validateExpression(it.value.(*RawExpr).SQL); err != nil {
+			return err
+		}
+		if err := validateExpr(it.value); err != nil {
+			return err
+		}
+	}
+	return validateWhere(s.dialect, s.conditions, s.allowWithoutWhere)
+}
+
+// 实现 ISQLQuery 接口
+type SQLDelete struct {
+	table             string
+	conditions        []IExpr
+	allowWithoutWhere bool
+	dialect           IDialect
+}
+
+func newSQLDelete() *SQLDelete {
+	return &SQLDelete{
+		conditions: make([]IExpr, 0),
+		dialect:    GenericDialect,
+	}
+}
+
+func (s *SQLDelete) ToSQL() string {
+	sql, _ := s.Render(s.dialect)
+	return sql
+}
+
+func (s *SQLDelete) Args() []interface{} {
+	_, args := s.Render(s.dialect)
+	return args
+}
+
+func (s *SQLDelete) Render(dialect IDialect) (string, []interface{}) {
+	b := newSQLWriter(dialect)
+
+	b.WriteString("DELETE FROM ")
+	b.writeIdentifier(s.table)
+
+	if len(s.conditions) > 0 {
+		b.WriteString(" WHERE ")
+		b.writeConditions(s.conditions)
+	}
+
+	return b.String(), b.args
+}
+
+type SQLDeleteBuilder struct {
+	delete *SQLDelete
+}
+
+func newSQLDeleteBuilder() ISQLDeleteBuilder {
+	return &SQLDeleteBuilder{
+		delete: newSQLDelete(),
+	}
+}
+
+func (s *SQLDeleteBuilder) WithTable(table string) ISQLDeleteBuilder {
+	s.delete.table = table
+	return s
+}
+
+func (s *SQLDeleteBuilder) WithCondition(condition string) ISQLDeleteBuilder {
+	return s.Where(condition)
+}
+
+func (s *SQLDeleteBuilder) Where(condition string, args ...interface{}) ISQLDeleteBuilder {
+	return s.WhereExpr(Raw(condition, args...))
+}
+
+func (s *SQLDeleteBuilder) WhereExpr(expr IExpr) ISQLDeleteBuilder {
+	s.delete.conditions = append(s.delete.conditions, expr)
+	return s
+}
+
+func (s *SQLDeleteBuilder) AllowWithoutWhere() ISQLDeleteBuilder {
+	s.delete.allowWithoutWhere = true
+	return s
+}
+
+func (s *SQLDeleteBuilder) WithDialect(dialect IDialect) ISQLDeleteBuilder {
+	s.delete.dialect = dialect
+	return s
+}
+
+func (s *SQLDeleteBuilder) Build() (error, ISQLQuery) {
+	if err := s.delete.validate(); err != nil {
+		return err, nil
+	}
+	return nil, s.delete.clone()
+}
+
+func (s *SQLDelete) clone() *SQLDelete {
+	query := *s
+	query.conditions = cloneExprs(s.conditions)
+	return &query
+}
+
+func (s *SQLDelete) validate() error {
+	if err := validateTable(s.table); err != nil {
+		return err
+	}
+	return validateWhere(s.dialect, s.conditions, s.allowWithoutWhere)
+}
+
+// 写入语句的表名只能是 a.b 形式的路径, 不允许别名与表达式
+func validateTable(table string) error {
+	if len(table) == 0 {
+		return ErrEmptyTable
+	}
+	if _, ok := quotePath(GenericDialect, table); !ok || strings.HasSuffix(table, "*") {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, table)
+	}
+	return nil
+}
+
+func validateColumns(groups ...[]string) error {
+	for _, columns := range groups {
+		for _, it := range columns {
+			if _, ok := quotePath(GenericDialect, it); !ok || strings.HasSuffix(it, "*") {
+				return fmt.Errorf("%w: %q", ErrInvalidIdentifier, it)
+			}
+		}
+	}
+	return nil
+}
+
+// UPDATE/DELETE 的条件不能为 nil、空的 SQL 片段或空的 AND/OR
+// 条件渲染后为空或恒为真时视为没有 WHERE 条件, 需要显式调用 AllowWithoutWhere()
+func validateWhere(dialect IDialect, conditions []IExpr, allowWithoutWhere bool) error {
+	for _, it := range conditions {
+		if err := validateCondition(it); err != nil {
+			return err
+		}
+	}
+	if allowWithoutWhere {
+		return nil
+	}
+
+	// 与渲染时一致, 未指定方言(nil)时按通用方言判断
+	b := newSQLWriter(dialect)
+	b.writeConditions(conditions)
+	where := strings.TrimSpace(b.String())
+	if len(conditions) == 0 || len(where) == 0 || strings.EqualFold(where, b.dialect.Bool(true)) {
+		return ErrMissingWhere
+	}
+	return nil
+}
+
+func validateCondition(expr IExpr) error {
+	if err := validateExpr(expr); err != nil {
+		return err
+	}
+
+	var err error
+	Walk(expr, func(expr IExpr) bool {
+		if err != nil {
+			return false
+		}
+		switch it := expr.(type) {
+		case *RawExpr:
+			if len(strings.TrimSpace(it.SQL)) == 0 {
+				err = fmt.Errorf("%w: empty SQL", ErrEmptyCondition)
+			}
+		case *AndExpr:
+			err = validateJunction("AND", it.Items)
+		case *OrExpr:
+			err = validateJunction("OR", it.Items)
+		}
+		return err == nil
+	})
+	return err
+}
+
+func validateJunction(op string, items []IExpr) error {
+	if len(items) == 0 {
+		return fmt.Errorf("%w: %s without conditions", ErrEmptyCondition, op)
+	}
+	return nil
+}
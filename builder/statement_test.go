@@ -0,0 +1,148 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_InsertBuilder(t *testing.T) {
+	expectSQL(t, mustBuild(t, newSQLInsertBuilder().
+		WithTable("product").
+		WithColumns("id", "name", "price").
+		WithValues(1, "tv", 100)), GenericDialect,
+		"INSERT INTO product (id,name,price) VALUES (?,?,?)", 1, "tv", 100)
+
+	expectSQL(t, mustBuild(t, newSQLInsertBuilder().
+		WithTable("product").
+		WithColumns("id", "name").
+		WithValues(1, "tv").
+		WithValues(2, "fridge").
+		OnDuplicateKeyUpdate("name")), GenericDialect,
+		"INSERT INTO product (id,name) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE name=VALUES(name)",
+		1, "tv", 2, "fridge")
+
+	expectSQL(t, mustBuild(t, newSQLInsertBuilder().
+		WithTable("product").
+		WithColumns("id", "name", "price").
+		WithValues(1, "tv", 100).
+		OnConflictUpdate([]string{"id"}, "name", "price")), GenericDialect,
+		"INSERT INTO product (id,name,price) VALUES (?,?,?) ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name,price=EXCLUDED.price",
+		1, "tv", 100)
+
+	expectSQL(t, mustBuild(t, newSQLInsertBuilder().
+		WithTable("product").
+		WithColumns("id").
+		WithValues(1).
+		OnConflictDoNothing()), GenericDialect,
+		"INSERT INTO product (id) VALUES (?) ON CONFLICT DO NOTHING", 1)
+
+	if err, _ := newSQLInsertBuilder().WithColumns("id").WithValues(1).Build(); err != ErrEmptyTable {
+		t.Fatalf("expecting ErrEmptyTable, got %v", err)
+	}
+	if err, _ := newSQLInsertBuilder().WithTable("product").Build(); err != ErrEmptyColumns {
+		t.Fatalf("expecting ErrEmptyColumns, got %v", err)
+	}
+	if err, _ := newSQLInsertBuilder().WithTable("product").WithColumns("id", "name").WithValues(1).Build(); err != ErrValueCount {
+		t.Fatalf("expecting ErrValueCount, got %v", err)
+	}
+}
+
+func Test_UpdateBuilder(t *testing.T) {
+	expectSQL(t, mustBuild(t, newSQLUpdateBuilder().
+		WithTable("product").
+		WithSet("name", "tv").
+		WithSetExpr("stock", "stock - ?", 1).
+		Where("id = ?", 7)), GenericDialect,
+		"UPDATE product SET name=?,stock=stock - ? WHERE id = ?", "tv", 1, 7)
+
+	if err, _ := newSQLUpdateBuilder().WithTable("product").WithSet("enable", 0).Build(); err != ErrMissingWhere {
+		t.Fatalf("expecting ErrMissingWhere, got %v", err)
+	}
+	expectSQL(t, mustBuild(t, newSQLUpdateBuilder().WithTable("product").WithSet("enable", 0).AllowWithoutWhere()), GenericDialect,
+		"UPDATE product SET enable=?", 0)
+
+	if err, _ := newSQLUpdateBuilder().WithTable("product").WithCondition("id=1").Build(); err != ErrEmptyColumns {
+		t.Fatalf("expecting ErrEmptyColumns, got %v", err)
+	}
+}
+
+func Test_DeleteBuilder(t *testing.T) {
+	expectSQL(t, mustBuild(t, newSQLDeleteBuilder().
+		WithTable("product").
+		WithCondition("enable=0").
+		Where("price < ?", 10)), GenericDialect,
+		"DELETE FROM product WHERE (enable=0) AND (price < ?)", 10)
+
+	if err, _ := newSQLDeleteBuilder().WithTable("product").Build(); err != ErrMissingWhere {
+		t.Fatalf("expecting ErrMissingWhere, got %v", err)
+	}
+	expectSQL(t, mustBuild(t, newSQLDeleteBuilder().WithTable("product").AllowWithoutWhere()), GenericDialect,
+		"DELETE FROM product")
+}
+
+func Test_StatementValidate(t *testing.T) {
+	cases := []struct {
+		builder interface{ Build() (error, ISQLQuery) }
+		err     error
+	}{
+		{newSQLDeleteBuilder().WithTable("users").WhereExpr(And()), ErrEmptyCondition},
+		{newSQLDeleteBuilder().WithTable("users").WhereExpr(Or(Eq("id", 1), And())), ErrEmptyCondition},
+		{newSQLDeleteBuilder().WithTable("users").WhereExpr(And([]IExpr{}...)), ErrEmptyCondition},
+		{newSQLDeleteBuilder().WithTable("users").Where(""), ErrEmptyCondition},
+		{newSQLDeleteBuilder().WithTable("users").WhereExpr(nil), ErrNilExpr},
+		{newSQLDeleteBuilder().WithTable("users").WhereExpr(Not(nil)), ErrNilExpr},
+		{newSQLDeleteBuilder().WithTable("users").Where("TRUE"), ErrMissingWhere},
+		{newSQLDeleteBuilder().WithTable("users").WhereExpr(Eq("id = 1 OR 1", 1)), ErrInvalidIdentifier},
+		{newSQLUpdateBuilder().WithTable("users").WithSet("name", "x").WhereExpr(&CompareExpr{Column: "id", Op: "LIKE", Value: 1}), ErrInvalidOperator},
+		{newSQLDeleteBuilder().WithTable("users; DROP TABLE x").Where("id = ?", 1), ErrInvalidIdentifier},
+		{newSQLUpdateBuilder().WithTable("users").WithSet("name", "x").WhereExpr(And()), ErrEmptyCondition},
+		{newSQLUpdateBuilder().WithTable("users").WithSet("name", "x").Where(" "), ErrEmptyCondition},
+		{newSQLUpdateBuilder().WithTable("users u").WithSet("name", "x").Where("id = ?", 1), ErrInvalidIdentifier},
+		{newSQLUpdateBuilder().WithTable("users").WithSet("name=1;", "x").Where("id = ?", 1), ErrInvalidIdentifier},
+		{newSQLUpdateBuilder().WithTable("users").WithSetExpr("name", "1; DROP TABLE x").Where("id = ?", 1), ErrUnsafeExpression},
+		{newSQLUpdateBuilder().WithTable("users").WithSetExpr("stock", "stock - ?").Where("id = ?", 1), ErrArgCount},
+		{newSQLDeleteBuilder().WithTable("users").Where("id = ? AND org_id = ?", 1), ErrArgCount},
+		{newSQLInsertBuilder().WithTable("users; DROP TABLE x").WithColumns("id").WithValues(1), ErrInvalidIdentifier},
+		{newSQLInsertBuilder().WithTable("users").WithColumns("id) VALUES (1); --").WithValues(1), ErrInvalidIdentifier},
+	}
+	for _, it := range cases {
+		if err, _ := it.builder.Build(); !errors.Is(err, it.err) {
+			t.Fatalf("expecting %v, got %v", it.err, err)
+		}
+	}
+
+	// 方言为 nil 时按通用方言构建与渲染
+	expectSQL(t, mustBuild(t, newSQLDeleteBuilder().WithTable("users").Where("id = ?", 1).WithDialect(nil)), nil,
+		"DELETE FROM users WHERE id = ?", 1)
+	expectSQL(t, mustBuild(t, newSQLUpdateBuilder().WithTable("users").WithSet("name", "x").WhereExpr(Eq("id", 1)).WithDialect(nil)), nil,
+		"UPDATE users SET name=? WHERE id = ?", "x", 1)
+	if err, _ := newSQLDeleteBuilder().WithTable("users").WhereExpr(And()).WithDialect(nil).AllowWithoutWhere().Build(); !errors.Is(err, ErrEmptyCondition) {
+		t.Fatalf("expecting ErrEmptyCondition, got %v", err)
+	}
+	if err, _ := newSQLDeleteBuilder().WithTable("users").Where("TRUE").WithDialect(nil).Build(); !errors.Is(err, ErrMissingWhere) {
+		t.Fatalf("expecting ErrMissingWhere, got %v", err)
+	}
+
+	// 显式允许全表操作时, 空的 AND 仍然视为错误, 以免误用
+	if err, _ := newSQLDeleteBuilder().WithTable("users").WhereExpr(And()).AllowWithoutWhere().Build(); !errors.Is(err, ErrEmptyCondition) {
+		t.Fatalf("expecting ErrEmptyCondition, got %v", err)
+	}
+}
+
+func Test_StatementBuildSnapshot(t *testing.T) {
+	// Build 返回的语句不受之后对建造者的修改影响
+	insert := newSQLInsertBuilder().WithTable("product").WithColumns("id").WithValues(1)
+	insertQuery := mustBuild(t, insert)
+	insert.WithValues(2).WithTable("other")
+	expectSQL(t, insertQuery, GenericDialect, "INSERT INTO product (id) VALUES (?)", 1)
+
+	update := newSQLUpdateBuilder().WithTable("product").WithSet("name", "tv").Where("id = ?", 1)
+	updateQuery := mustBuild(t, update)
+	update.WithSet("price", 0).Where("enable = ?", 1)
+	expectSQL(t, updateQuery, GenericDialect, "UPDATE product SET name=? WHERE id = ?", "tv", 1)
+
+	remove := newSQLDeleteBuilder().WithTable("product").Where("id = ?", 1)
+	deleteQuery := mustBuild(t, remove)
+	remove.Where("enable = ?", 1).WithTable("other")
+	expectSQL(t, deleteQuery, GenericDialect, "DELETE FROM product WHERE id = ?", 1)
+}
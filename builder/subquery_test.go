@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"testing"
+)
+
+func Test_Subquery(t *testing.T) {
+	paid := mustBuild(t, newSQLQueryBuilder().
+		WithTable("orders").WithField("user_id").
+		Where("status = ?", "paid"))
+
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("user_info").WithField("id").WithField("name").
+		Where("org_id = ?", 11).
+		WhereExpr(InQuery("id", paid)).
+		WhereExpr(Gt("age", mustBuild(t, newSQLQueryBuilder().WithTable("user_info").WithField("avg(age)").Where("org_id = ?", 12)))))
+	expectSQL(t, query, PostgreSQLDialect,
+		`SELECT "id","name" FROM "user_info" WHERE (org_id = $1) AND "id" IN (SELECT "user_id" FROM "orders" WHERE status = $2)`+
+			` AND "age" > (SELECT avg(age) FROM "user_info" WHERE org_id = $3)`,
+		11, "paid", 12)
+
+	query = mustBuild(t, newSQLQueryBuilder().
+		WithTableQuery(paid, "p").
+		WithField("p.user_id").
+		WhereExpr(NotInQuery("p.user_id", mustBuild(t, newSQLQueryBuilder().WithTable("blacklist").WithField("user_id")))))
+	expectSQL(t, query, GenericDialect,
+		"SELECT p.user_id FROM (SELECT user_id FROM orders WHERE status = ?) p WHERE p.user_id NOT IN (SELECT user_id FROM blacklist)",
+		"paid")
+}
+
+func Test_SetOperation(t *testing.T) {
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("customer").WithField("name").
+		Where("city = ?", "广州").
+		WithUnionAll(mustBuild(t, newSQLQueryBuilder().WithTable("supplier").WithField("name").Where("city = ?", "深圳"))).
+		WithUnion(mustBuild(t, newSQLQueryBuilder().WithTable("staff").WithField("name").WithOrder("name", Asc).WithLimit(3))).
+		WithSetOperation(Except, mustBuild(t, newSQLQueryBuilder().WithTable("blacklist").WithField("name"))).
+		WithOrder("name", Desc).
+		WithLimit(10))
+	expectSQL(t, query, PostgreSQLDialect,
+		`SELECT "name" FROM "customer" WHERE city = $1`+
+			` UNION ALL SELECT "name" FROM "supplier" WHERE city = $2`+
+			` UNION (SELECT "name" FROM "staff" ORDER BY "name" ASC LIMIT 3)`+
+			` EXCEPT SELECT "name" FROM "blacklist"`+
+			` ORDER BY "name" DESC LIMIT 10`,
+		"广州", "深圳")
+}
+
+func Test_CTE(t *testing.T) {
+	recent := mustBuild(t, newSQLQueryBuilder().
+		WithTable("orders").WithField("user_id").WithField("sum(amount) total").
+		Where("created_at > ?", "2020-01-01").
+		WithGroupBy("user_id"))
+	vip := mustBuild(t, newSQLQueryBuilder().
+		WithTable("recent").WithField("user_id").
+		Where("total > ?", 1000))
+
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithCTE("recent", recent).
+		WithCTE("vip", vip).
+		WithTable("user_info u").WithField("u.name").
+		WithJoin("vip v", "v.user_id = u.id AND u.enable = ?", 1).
+		Where("u.org_id = ?", 11))
+	expectSQL(t, query, PostgreSQLDialect,
+		`WITH "recent" AS (SELECT "user_id",sum(amount) total FROM "orders" WHERE created_at > $1 GROUP BY "user_id"),`+
+			`"vip" AS (SELECT "user_id" FROM "recent" WHERE total > $2)`+
+			` SELECT "u"."name" FROM "user_info" "u" JOIN "vip" "v" ON v.user_id = u.id AND u.enable = $3 WHERE u.org_id = $4`,
+		"2020-01-01", 1000, 1, 11)
+}
+
+// 非 SQLQuery 实现的 ISQLQuery 也可以作为子查询, 其占位符会被替换为外层方言的占位符
+type rawQuery struct {
+	sql  string
+	args []interface{}
+}
+
+func (r *rawQuery) ToSQL() string {
+	return r.sql
+}
+
+func (r *rawQuery) Args() []interface{} {
+	return r.args
+}
+
+func (r *rawQuery) Render(dialect IDialect) (string, []interface{}) {
+	return r.sql, r.args
+}
+
+func Test_SubqueryForeign(t *testing.T) {
+	query := mustBuild(t, newSQLQueryBuilder().
+		WithTable("user_info").WithField("id").
+		Where("enable = ?", 1).
+		WhereExpr(InQuery("id", &rawQuery{sql: "SELECT user_id FROM vip WHERE level > ?", args: []interface{}{3}})))
+	expectSQL(t, query, PostgreSQLDialect,
+		`SELECT "id" FROM "user_info" WHERE (enable = $1) AND "id" IN (SELECT user_id FROM vip WHERE level > $2)`,
+		1, 3)
+}
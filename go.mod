@@ -2,4 +2,7 @@ module github.com/landians/design-mode
 
 go 1.15
 
-require github.com/DATA-DOG/go-sqlmock v1.5.0
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,167 @@
+package iterator
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrQueueClosed = errors.New("queue closed")
+	ErrQueueFull   = errors.New("queue full")
+	ErrQueueEmpty  = errors.New("queue empty")
+)
+
+// 有界阻塞队列接口, 可在多个 goroutine 之间安全地用作工作队列
+type IBlockingQueue interface {
+	Size() int
+	Capacity() int
+	// 队列满时阻塞, 直到有空位、ctx 结束或队列关闭
+	Push(ctx context.Context, it interface{}) error
+	// 队列空时阻塞, 直到有元素、ctx 结束或队列关闭
+	Poll(ctx context.Context) (error, interface{})
+	TryPush(it interface{}) error
+	TryPoll() (error, interface{})
+	// 关闭后 Push 立即失败, Poll 取完剩余元素后返回 ErrQueueClosed
+	Close() error
+	// 返回当前元素的快照迭代器
+	Iterator() IIterator
+}
+
+// 实现 IBlockingQueue 接口, 内部使用 xLinkedList 存储元素
+type xBlockingQueue struct {
+	mu       sync.Mutex
+	items    *xLinkedList
+	capacity int
+	closed   bool
+	changed  chan struct{} // 队列状态变化时关闭并替换, 以唤醒所有等待者
+}
+
+// capacity <= 0 表示不限容量
+func newBlockingQueue(capacity int) IBlockingQueue {
+	return &xBlockingQueue{
+		items:    &xLinkedList{},
+		capacity: capacity,
+		changed:  make(chan struct{}),
+	}
+}
+
+func (q *xBlockingQueue) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Size()
+}
+
+func (q *xBlockingQueue) Capacity() int {
+	return q.capacity
+}
+
+// 需持有锁调用
+func (q *xBlockingQueue) broadcast() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// 需持有锁调用
+func (q *xBlockingQueue) full() bool {
+	return q.capacity > 0 && q.items.Size() >= q.capacity
+}
+
+// 需持有锁调用
+func (q *xBlockingQueue) tryPush(it interface{}) error {
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if q.full() {
+		return ErrQueueFull
+	}
+
+	q.items.Push(it)
+	q.broadcast()
+	return nil
+}
+
+// 需持有锁调用
+func (q *xBlockingQueue) tryPoll() (error, interface{}) {
+	if q.items.Size() == 0 {
+		if q.closed {
+			return ErrQueueClosed, nil
+		}
+		return ErrQueueEmpty, nil
+	}
+
+	err, it := q.items.Poll()
+	if err != nil {
+		return err, nil
+	}
+	q.broadcast()
+	return nil, it
+}
+
+func (q *xBlockingQueue) Push(ctx context.Context, it interface{}) error {
+	for {
+		q.mu.Lock()
+		err := q.tryPush(it)
+		wait := q.changed
+		q.mu.Unlock()
+
+		if err != ErrQueueFull {
+			return err
+		}
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (q *xBlockingQueue) Poll(ctx context.Context) (error, interface{}) {
+	for {
+		q.mu.Lock()
+		err, it := q.tryPoll()
+		wait := q.changed
+		q.mu.Unlock()
+
+		if err != ErrQueueEmpty {
+			return err, it
+		}
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err(), nil
+		}
+	}
+}
+
+func (q *xBlockingQueue) TryPush(it interface{}) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.tryPush(it)
+}
+
+func (q *xBlockingQueue) TryPoll() (error, interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.tryPoll()
+}
+
+func (q *xBlockingQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	q.closed = true
+	q.broadcast()
+	return nil
+}
+
+func (q *xBlockingQueue) Iterator() IIterator {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.SnapshotIterator()
+}
@@ -0,0 +1,126 @@
+package iterator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_BlockingQueue(t *testing.T) {
+	queue := newBlockingQueue(2)
+
+	if err := queue.TryPush(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.TryPush(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.TryPush(3); err != ErrQueueFull {
+		t.Fatalf("expecting ErrQueueFull, got %v", err)
+	}
+
+	// 队列已满, Push 应阻塞到超时
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := queue.Push(ctx, 3); err != context.DeadlineExceeded {
+		t.Fatalf("expecting DeadlineExceeded, got %v", err)
+	}
+
+	err, items := Collect(queue.Iterator())
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expecting 2 items in snapshot, got %v, %v", err, items)
+	}
+
+	if err, value := queue.TryPoll(); err != nil || value != 1 {
+		t.Fatalf("expecting TryPoll 1, got %v, %v", err, value)
+	}
+	if err, value := queue.Poll(context.Background()); err != nil || value != 2 {
+		t.Fatalf("expecting Poll 2, got %v, %v", err, value)
+	}
+	if err, _ := queue.TryPoll(); err != ErrQueueEmpty {
+		t.Fatalf("expecting ErrQueueEmpty, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err, _ := queue.Poll(ctx); err != context.Canceled {
+		t.Fatalf("expecting Canceled, got %v", err)
+	}
+}
+
+func Test_BlockingQueueWorkers(t *testing.T) {
+	const producers, consumers, count = 4, 3, 200
+	queue := newBlockingQueue(5)
+
+	var wgProducer sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wgProducer.Add(1)
+		go func(p int) {
+			defer wgProducer.Done()
+			for i := 0; i < count; i++ {
+				if err := queue.Push(context.Background(), p*count+i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(p)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var wgConsumer sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		wgConsumer.Add(1)
+		go func() {
+			defer wgConsumer.Done()
+			for {
+				err, value := queue.Poll(context.Background())
+				if err == ErrQueueClosed {
+					return
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				seen[value.(int)] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wgProducer.Wait()
+	if err := queue.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wgConsumer.Wait()
+
+	if len(seen) != producers*count {
+		t.Fatalf("expecting %d distinct items, got %d", producers*count, len(seen))
+	}
+	if err := queue.Push(context.Background(), 0); err != ErrQueueClosed {
+		t.Fatalf("expecting ErrQueueClosed, got %v", err)
+	}
+}
+
+func Test_BlockingQueueCloseWakesConsumers(t *testing.T) {
+	queue := newBlockingQueue(0)
+	done := make(chan error)
+	go func() {
+		err, _ := queue.Poll(context.Background())
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	_ = queue.Close()
+
+	select {
+	case err := <-done:
+		if err != ErrQueueClosed {
+			t.Fatalf("expecting ErrQueueClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expecting Close to wake the waiting consumer")
+	}
+}
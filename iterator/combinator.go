@@ -0,0 +1,305 @@
+package iterator
+
+import "errors"
+
+// 迭代器已耗尽时 Next() 返回的错误
+var ErrNoMoreElements = errors.New("no more elements")
+
+// 组合子使用的函数类型
+type FNMapper func(v interface{}) interface{}
+type FNPredicate func(v interface{}) bool
+type FNFlatMapper func(v interface{}) IIterator
+type FNReducer func(acc interface{}, v interface{}) interface{}
+
+// Zip 产生的元素
+type Pair struct {
+	First  interface{}
+	Second interface{}
+}
+
+// 惰性映射迭代器, 每次 Next() 时才对上游元素调用 fn
+type mapIterator struct {
+	src IIterator
+	fn  FNMapper
+}
+
+func Map(src IIterator, fn FNMapper) IIterator {
+	return &mapIterator{
+		src: src,
+		fn:  fn,
+	}
+}
+
+func (m *mapIterator) More() bool {
+	return m.src.More()
+}
+
+func (m *mapIterator) Next() (error, interface{}) {
+	err, v := m.src.Next()
+	if err != nil {
+		return err, nil
+	}
+	return nil, m.fn(v)
+}
+
+// 惰性过滤迭代器, More() 会向前读取直到找到满足条件的元素
+type filterIterator struct {
+	src   IIterator
+	fn    FNPredicate
+	ready bool
+	err   error
+	value interface{}
+}
+
+func Filter(src IIterator, fn FNPredicate) IIterator {
+	return &filterIterator{
+		src: src,
+		fn:  fn,
+	}
+}
+
+func (f *filterIterator) advance() {
+	for !f.ready && f.src.More() {
+		err, v := f.src.Next()
+		if err != nil || f.fn(v) {
+			f.ready, f.err, f.value = true, err, v
+		}
+	}
+}
+
+func (f *filterIterator) More() bool {
+	f.advance()
+	return f.ready
+}
+
+func (f *filterIterator) Next() (error, interface{}) {
+	f.advance()
+	if !f.ready {
+		return ErrNoMoreElements, nil
+	}
+
+	err, v := f.err, f.value
+	f.ready, f.err, f.value = false, nil, nil
+	if err != nil {
+		return err, nil
+	}
+	return nil, v
+}
+
+// 只取前 n 个元素的迭代器
+type takeIterator struct {
+	src   IIterator
+	n     int
+	taken int
+}
+
+func Take(src IIterator, n int) IIterator {
+	return &takeIterator{
+		src: src,
+		n:   n,
+	}
+}
+
+func (t *takeIterator) More() bool {
+	return t.taken < t.n && t.src.More()
+}
+
+func (t *takeIterator) Next() (error, interface{}) {
+	if t.taken >= t.n {
+		return ErrNoMoreElements, nil
+	}
+	t.taken++
+	return t.src.Next()
+}
+
+// 跳过前 n 个元素的迭代器, 跳过过程中遇到的错误仍会从 Next() 返回
+type skipIterator struct {
+	src       IIterator
+	remaining int
+	err       error
+}
+
+func Skip(src IIterator, n int) IIterator {
+	return &skipIterator{
+		src:       src,
+		remaining: n,
+	}
+}
+
+func (s *skipIterator) skip() {
+	for s.err == nil && s.remaining > 0 && s.src.More() {
+		s.remaining--
+		if err, _ := s.src.Next(); err != nil {
+			s.err = err
+		}
+	}
+}
+
+func (s *skipIterator) More() bool {
+	s.skip()
+	return s.err != nil || s.src.More()
+}
+
+func (s *skipIterator) Next() (error, interface{}) {
+	s.skip()
+	if s.err != nil {
+		err := s.err
+		s.err = nil
+		return err, nil
+	}
+	return s.src.Next()
+}
+
+// 将两个迭代器按位置配对, 任一耗尽即结束
+type zipIterator struct {
+	first  IIterator
+	second IIterator
+}
+
+func Zip(first IIterator, second IIterator) IIterator {
+	return &zipIterator{
+		first:  first,
+		second: second,
+	}
+}
+
+func (z *zipIterator) More() bool {
+	return z.first.More() && z.second.More()
+}
+
+func (z *zipIterator) Next() (error, interface{}) {
+	if !z.More() {
+		return ErrNoMoreElements, nil
+	}
+
+	// first 出错时不推进 second, 以免丢弃 second 中已取出的元素
+	// second 出错时 first 已取出的元素无法退回, 该元素被跳过
+	err, v1 := z.first.Next()
+	if err != nil {
+		return err, nil
+	}
+	err, v2 := z.second.Next()
+	if err != nil {
+		return err, nil
+	}
+	return nil, Pair{First: v1, Second: v2}
+}
+
+// 依次串联多个迭代器
+type chainIterator struct {
+	items []IIterator
+	index int
+}
+
+func Chain(items ...IIterator) IIterator {
+	return &chainIterator{
+		items: items,
+	}
+}
+
+func (c *chainIterator) More() bool {
+	for c.index < len(c.items) && !c.items[c.index].More() {
+		c.index++
+	}
+	return c.index < len(c.items)
+}
+
+func (c *chainIterator) Next() (error, interface{}) {
+	if !c.More() {
+		return ErrNoMoreElements, nil
+	}
+	return c.items[c.index].Next()
+}
+
+// 将每个元素映射为一个迭代器并展开, fn 返回 nil 视为空迭代器
+type flatMapIterator struct {
+	src   IIterator
+	fn    FNFlatMapper
+	inner IIterator
+	err   error
+}
+
+func FlatMap(src IIterator, fn FNFlatMapper) IIterator {
+	return &flatMapIterator{
+		src: src,
+		fn:  fn,
+	}
+}
+
+func (f *flatMapIterator) advance() {
+	for f.err == nil && (f.inner == nil || !f.inner.More()) && f.src.More() {
+		err, v := f.src.Next()
+		if err != nil {
+			f.err = err
+			return
+		}
+		f.inner = f.fn(v)
+	}
+}
+
+func (f *flatMapIterator) More() bool {
+	f.advance()
+	return f.err != nil || (f.inner != nil && f.inner.More())
+}
+
+func (f *flatMapIterator) Next() (error, interface{}) {
+	if !f.More() {
+		return ErrNoMoreElements, nil
+	}
+
+	if f.err != nil {
+		err := f.err
+		f.err = nil
+		return err, nil
+	}
+	return f.inner.Next()
+}
+
+// 以 seed 开始, 反复调用 fn 生成的无限迭代器
+type iterateIterator struct {
+	value interface{}
+	fn    FNMapper
+}
+
+func Iterate(seed interface{}, fn FNMapper) IIterator {
+	return &iterateIterator{
+		value: seed,
+		fn:    fn,
+	}
+}
+
+func (i *iterateIterator) More() bool {
+	return true
+}
+
+func (i *iterateIterator) Next() (error, interface{}) {
+	v := i.value
+	i.value = i.fn(v)
+	return nil, v
+}
+
+// 归约迭代器的全部元素, 遇到第一个错误即停止
+func Reduce(src IIterator, initial interface{}, fn FNReducer) (error, interface{}) {
+	acc := initial
+	for src.More() {
+		err, v := src.Next()
+		if err != nil {
+			return err, nil
+		}
+		acc = fn(acc, v)
+	}
+	return nil, acc
+}
+
+// 将迭代器的全部元素收集到切片中, 遇到第一个错误即停止
+func Collect(src IIterator) (error, []interface{}) {
+	items := make([]interface{}, 0)
+	for src.More() {
+		err, v := src.Next()
+		if err != nil {
+			return err, nil
+		}
+		items = append(items, v)
+	}
+	return nil, items
+}
@@ -0,0 +1,178 @@
+package iterator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newTestList(values ...interface{}) ILinkedList {
+	list := newLinkedList()
+	for _, v := range values {
+		list.Push(v)
+	}
+	return list
+}
+
+// 自然数无限生成器: 0, 1, 2, ...
+func naturals() IIterator {
+	return Iterate(0, func(v interface{}) interface{} {
+		return v.(int) + 1
+	})
+}
+
+// 第 failAt 次调用 Next() 时返回错误的迭代器
+type failingIterator struct {
+	src    IIterator
+	count  int
+	failAt int
+}
+
+var errTestFailure = errors.New("test failure")
+
+func (f *failingIterator) More() bool {
+	return f.src.More()
+}
+
+func (f *failingIterator) Next() (error, interface{}) {
+	f.count++
+	err, v := f.src.Next()
+	if f.count == f.failAt {
+		return errTestFailure, nil
+	}
+	return err, v
+}
+
+func expectCollect(t *testing.T, it IIterator, expected ...interface{}) {
+	t.Helper()
+	err, items := Collect(it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) == 0 {
+		expected = []interface{}{}
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatalf("expecting %v, got %v", expected, items)
+	}
+}
+
+func Test_Combinator(t *testing.T) {
+	square := func(v interface{}) interface{} {
+		return v.(int) * v.(int)
+	}
+	even := func(v interface{}) bool {
+		return v.(int)%2 == 0
+	}
+
+	expectCollect(t, Map(newTestList(1, 2, 3).Iterator(), square), 1, 4, 9)
+	expectCollect(t, Filter(newTestList(1, 2, 3, 4).Iterator(), even), 2, 4)
+	expectCollect(t, Take(newTestList(1, 2, 3).Iterator(), 2), 1, 2)
+	expectCollect(t, Take(newTestList(1, 2, 3).Iterator(), 5), 1, 2, 3)
+	expectCollect(t, Skip(newTestList(1, 2, 3).Iterator(), 2), 3)
+	expectCollect(t, Skip(newTestList(1, 2, 3).Iterator(), 5))
+	expectCollect(t, Chain(newTestList(1).Iterator(), newTestList().Iterator(), newTestList(2, 3).Iterator()), 1, 2, 3)
+	expectCollect(t,
+		Zip(newTestList(1, 2, 3).Iterator(), newTestList("a", "b").Iterator()),
+		Pair{First: 1, Second: "a"}, Pair{First: 2, Second: "b"})
+	expectCollect(t,
+		FlatMap(newTestList(0, 1, 2).Iterator(), func(v interface{}) IIterator {
+			if v.(int) == 0 {
+				return nil
+			}
+			return Take(naturals(), v.(int))
+		}),
+		0, 0, 1)
+
+	err, sum := Reduce(newTestList(1, 2, 3, 4).Iterator(), 0, func(acc interface{}, v interface{}) interface{} {
+		return acc.(int) + v.(int)
+	})
+	if err != nil || sum != 10 {
+		t.Fatalf("expecting sum 10, got %v, %v", err, sum)
+	}
+}
+
+func Test_CombinatorInfinite(t *testing.T) {
+	// 对无限生成器的组合必须是惰性的, 否则 Take 之前就会死循环
+	it := Take(Filter(Map(naturals(), func(v interface{}) interface{} {
+		return v.(int) * 3
+	}), func(v interface{}) bool {
+		return v.(int)%2 == 1
+	}), 3)
+	expectCollect(t, it, 3, 9, 15)
+
+	expectCollect(t, Take(Skip(naturals(), 10), 2), 10, 11)
+	expectCollect(t,
+		Take(Zip(naturals(), Skip(naturals(), 1)), 2),
+		Pair{First: 0, Second: 1}, Pair{First: 1, Second: 2})
+}
+
+func Test_CombinatorError(t *testing.T) {
+	identity := func(v interface{}) interface{} {
+		return v
+	}
+	always := func(v interface{}) bool {
+		return true
+	}
+	fnFailing := func() IIterator {
+		return &failingIterator{src: newTestList(1, 2, 3).Iterator(), failAt: 2}
+	}
+
+	cases := map[string]IIterator{
+		"map":     Map(fnFailing(), identity),
+		"filter":  Filter(fnFailing(), always),
+		"take":    Take(fnFailing(), 3),
+		"skip":    Skip(fnFailing(), 1),
+		"chain":   Chain(newTestList().Iterator(), fnFailing()),
+		"zip":     Zip(fnFailing(), naturals()),
+		"flatMap": FlatMap(fnFailing(), func(v interface{}) IIterator { return newTestList(v).Iterator() }),
+	}
+	for name, it := range cases {
+		if err, _ := Collect(it); err != errTestFailure {
+			t.Fatalf("%s: expecting errTestFailure, got %v", name, err)
+		}
+	}
+
+	err, _ := Reduce(fnFailing(), 0, func(acc interface{}, v interface{}) interface{} {
+		return acc
+	})
+	if err != errTestFailure {
+		t.Fatalf("reduce: expecting errTestFailure, got %v", err)
+	}
+
+	// 错误不会终止迭代, 后续元素仍可继续读取
+	it := Map(fnFailing(), identity)
+	var values []interface{}
+	for it.More() {
+		if err, v := it.Next(); err == nil {
+			values = append(values, v)
+		}
+	}
+	if !reflect.DeepEqual(values, []interface{}{1, 3}) {
+		t.Fatalf("expecting [1 3], got %v", values)
+	}
+
+	// zip 的 first 出错时不推进 second; second 出错时 first 已取出的元素被跳过
+	zipCases := []struct {
+		it       IIterator
+		expected []interface{}
+	}{
+		{Zip(fnFailing(), newTestList("a", "b", "c").Iterator()), []interface{}{Pair{First: 1, Second: "a"}, Pair{First: 3, Second: "b"}}},
+		{Zip(newTestList("a", "b", "c").Iterator(), fnFailing()), []interface{}{Pair{First: "a", Second: 1}, Pair{First: "c", Second: 3}}},
+	}
+	for _, it := range zipCases {
+		values = nil
+		for it.it.More() {
+			if err, v := it.it.Next(); err == nil {
+				values = append(values, v)
+			}
+		}
+		if !reflect.DeepEqual(values, it.expected) {
+			t.Fatalf("zip: expecting %v, got %v", it.expected, values)
+		}
+	}
+
+	if err, _ := Take(naturals(), 0).Next(); err != ErrNoMoreElements {
+		t.Fatalf("expecting ErrNoMoreElements, got %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+package iterator
+
+import "errors"
+
+var (
+	ErrEmptyList = errors.New("empty list")
+	// 尚未调用 Next()/Previous(), 或当前元素已被 Remove() 时返回
+	ErrIllegalIteratorState = errors.New("iterator has no current element")
+)
+
+// 双端队列接口, 在 ILinkedList 的基础上支持两端的插入、删除与查看
+type IDeque interface {
+	ILinkedList
+	PushFront(it interface{})
+	PopBack() (error, interface{})
+	PeekFront() (error, interface{})
+	PeekBack() (error, interface{})
+	ListIterator() IListIterator
+}
+
+// 双向迭代器接口, 可在 O(1) 时间内删除、替换当前元素或在其前后插入元素
+// 当前元素指最近一次 Next()/Previous() 返回的元素, 插入操作不移动游标
+type IListIterator interface {
+	IIterator
+	HasPrevious() bool
+	Previous() (error, interface{})
+	Remove() error
+	Set(it interface{}) error
+	InsertBefore(it interface{}) error
+	InsertAfter(it interface{}) error
+}
+
+// 双向链表节点
+type xDequeNode struct {
+	value interface{}
+	prev  *xDequeNode
+	next  *xDequeNode
+}
+
+// 实现 IDeque 接口
+type xLinkedDeque struct {
+	size     int
+	head     *xDequeNode
+	tail     *xDequeNode
+	modCount int
+}
+
+func newLinkedDeque() IDeque {
+	return &xLinkedDeque{}
+}
+
+// 在 succ 之前插入新节点, succ 为 nil 时追加到队尾
+func (d *xLinkedDeque) linkBefore(v interface{}, succ *xDequeNode) {
+	node := &xDequeNode{value: v, next: succ}
+	if succ == nil {
+		node.prev = d.tail
+		d.tail = node
+	} else {
+		node.prev = succ.prev
+		succ.prev = node
+	}
+
+	if node.prev == nil {
+		d.head = node
+	} else {
+		node.prev.next = node
+	}
+	d.size++
+	d.modCount++
+}
+
+func (d *xLinkedDeque) unlink(node *xDequeNode) interface{} {
+	if node.prev == nil {
+		d.head = node.next
+	} else {
+		node.prev.next = node.next
+	}
+
+	if node.next == nil {
+		d.tail = node.prev
+	} else {
+		node.next.prev = node.prev
+	}
+
+	node.prev, node.next = nil, nil
+	d.size--
+	d.modCount++
+	return node.value
+}
+
+func (d *xLinkedDeque) Size() int {
+	return d.size
+}
+
+func (d *xLinkedDeque) Push(it interface{}) {
+	d.linkBefore(it, nil)
+}
+
+func (d *xLinkedDeque) PushFront(it interface{}) {
+	d.linkBefore(it, d.head)
+}
+
+func (d *xLinkedDeque) Poll() (error, interface{}) {
+	if d.head == nil {
+		return ErrEmptyList, nil
+	}
+	return nil, d.unlink(d.head)
+}
+
+func (d *xLinkedDeque) PopBack() (error, interface{}) {
+	if d.tail == nil {
+		return ErrEmptyList, nil
+	}
+	return nil, d.unlink(d.tail)
+}
+
+func (d *xLinkedDeque) PeekFront() (error, interface{}) {
+	if d.head == nil {
+		return ErrEmptyList, nil
+	}
+	return nil, d.head.value
+}
+
+func (d *xLinkedDeque) PeekBack() (error, interface{}) {
+	if d.tail == nil {
+		return ErrEmptyList, nil
+	}
+	return nil, d.tail.value
+}
+
+func (d *xLinkedDeque) Iterator() IIterator {
+	return d.ListIterator()
+}
+
+func (d *xLinkedDeque) ListIterator() IListIterator {
+	return newDequeIterator(d)
+}
+
+func (d *xLinkedDeque) Spliterator() ISpliterator {
+	return &xDequeSpliterator{
+		deque:            d,
+		current:          d.head,
+		remaining:        d.size,
+		expectedModCount: d.modCount,
+	}
+}
+
+func (d *xLinkedDeque) SnapshotIterator() IIterator {
+	values := make([]interface{}, 0, d.size)
+	for node := d.head; node != nil; node = node.next {
+		values = append(values, node.value)
+	}
+	return newSliceIterator(values)
+}
+
+// 双端队列迭代器, 实现 IListIterator 接口
+// 通过迭代器自身进行的修改会同步 expectedModCount, 其他修改则快速失败
+type xDequeIterator struct {
+	deque            *xLinkedDeque
+	next             *xDequeNode // Next() 将返回的节点, nil 表示游标位于队尾
+	lastReturned     *xDequeNode
+	expectedModCount int
+}
+
+func newDequeIterator(deque *xLinkedDeque) IListIterator {
+	return &xDequeIterator{
+		deque:            deque,
+		next:             deque.head,
+		expectedModCount: deque.modCount,
+	}
+}
+
+func (d *xDequeIterator) checkModification() error {
+	if d.deque.modCount != d.expectedModCount {
+		return ErrConcurrentModification
+	}
+	return nil
+}
+
+func (d *xDequeIterator) previous() *xDequeNode {
+	if d.next == nil {
+		return d.deque.tail
+	}
+	return d.next.prev
+}
+
+func (d *xDequeIterator) More() bool {
+	return d.next != nil && d.checkModification() == nil
+}
+
+func (d *xDequeIterator) HasPrevious() bool {
+	return d.previous() != nil && d.checkModification() == nil
+}
+
+func (d *xDequeIterator) Next() (error, interface{}) {
+	if err := d.checkModification(); err != nil {
+		return err, nil
+	}
+	if d.next == nil {
+		return ErrNoMoreElements, nil
+	}
+
+	d.lastReturned = d.next
+	d.next = d.next.next
+	return nil, d.lastReturned.value
+}
+
+func (d *xDequeIterator) Previous() (error, interface{}) {
+	if err := d.checkModification(); err != nil {
+		return err, nil
+	}
+
+	node := d.previous()
+	if node == nil {
+		return ErrNoMoreElements, nil
+	}
+
+	d.next = node
+	d.lastReturned = node
+	return nil, node.value
+}
+
+func (d *xDequeIterator) Remove() error {
+	if err := d.checkModification(); err != nil {
+		return err
+	}
+	if d.lastReturned == nil {
+		return ErrIllegalIteratorState
+	}
+
+	if d.next == d.lastReturned {
+		d.next = d.lastReturned.next
+	}
+	d.deque.unlink(d.lastReturned)
+	d.lastReturned = nil
+	d.expectedModCount = d.deque.modCount
+	return nil
+}
+
+func (d *xDequeIterator) Set(it interface{}) error {
+	if err := d.checkModification(); err != nil {
+		return err
+	}
+	if d.lastReturned == nil {
+		return ErrIllegalIteratorState
+	}
+
+	d.lastReturned.value = it
+	return nil
+}
+
+func (d *xDequeIterator) InsertBefore(it interface{}) error {
+	if err := d.checkModification(); err != nil {
+		return err
+	}
+	if d.lastReturned == nil {
+		return ErrIllegalIteratorState
+	}
+
+	d.deque.linkBefore(it, d.lastReturned)
+	d.expectedModCount = d.deque.modCount
+	return nil
+}
+
+func (d *xDequeIterator) InsertAfter(it interface{}) error {
+	if err := d.checkModification(); err != nil {
+		return err
+	}
+	if d.lastReturned == nil {
+		return ErrIllegalIteratorState
+	}
+
+	d.deque.linkBefore(it, d.lastReturned.next)
+	d.expectedModCount = d.deque.modCount
+	return nil
+}
+
+// 双端队列的可拆分迭代器, 实现 ISpliterator 接口
+type xDequeSpliterator struct {
+	deque            *xLinkedDeque
+	current          *xDequeNode
+	remaining        int
+	expectedModCount int
+}
+
+func (d *xDequeSpliterator) More() bool {
+	return d.remaining > 0 && d.deque.modCount == d.expectedModCount
+}
+
+func (d *xDequeSpliterator) Next() (error, interface{}) {
+	if d.deque.modCount != d.expectedModCount {
+		return ErrConcurrentModification, nil
+	}
+	if d.remaining <= 0 {
+		return ErrNoMoreElements, nil
+	}
+
+	node := d.current
+	d.current = node.next
+	d.remaining--
+	return nil, node.value
+}
+
+func (d *xDequeSpliterator) EstimateSize() int {
+	return d.remaining
+}
+
+func (d *xDequeSpliterator) TrySplit() ISpliterator {
+	if d.remaining < 2 {
+		return nil
+	}
+
+	half := d.remaining / 2
+	prefix := &xDequeSpliterator{
+		deque:            d.deque,
+		current:          d.current,
+		remaining:        half,
+		expectedModCount: d.expectedModCount,
+	}
+	for i := 0; i < half; i++ {
+		d.current = d.current.next
+	}
+	d.remaining -= half
+	return prefix
+}
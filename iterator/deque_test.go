@@ -0,0 +1,134 @@
+package iterator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func expectDeque(t *testing.T, deque IDeque, expected ...interface{}) {
+	t.Helper()
+	err, items := Collect(deque.SnapshotIterator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) == 0 {
+		expected = []interface{}{}
+	}
+	if !reflect.DeepEqual(items, expected) || deque.Size() != len(expected) {
+		t.Fatalf("expecting %v (size %d), got %v (size %d)", expected, len(expected), items, deque.Size())
+	}
+
+	// 反向遍历应得到相反的顺序
+	iter := deque.ListIterator()
+	for iter.More() {
+		_, _ = iter.Next()
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		if err, value := iter.Previous(); err != nil || value != expected[i] {
+			t.Fatalf("expecting previous %v, got %v, %v", expected[i], err, value)
+		}
+	}
+	if iter.HasPrevious() {
+		t.Fatal("expecting no previous element")
+	}
+}
+
+func Test_Deque(t *testing.T) {
+	deque := newLinkedDeque()
+	if err, _ := deque.PopBack(); err != ErrEmptyList {
+		t.Fatalf("expecting ErrEmptyList, got %v", err)
+	}
+	if err, _ := deque.PeekFront(); err != ErrEmptyList {
+		t.Fatalf("expecting ErrEmptyList, got %v", err)
+	}
+
+	deque.Push(2)
+	deque.Push(3)
+	deque.PushFront(1)
+	expectDeque(t, deque, 1, 2, 3)
+
+	if err, value := deque.PeekFront(); err != nil || value != 1 {
+		t.Fatalf("expecting PeekFront 1, got %v, %v", err, value)
+	}
+	if err, value := deque.PeekBack(); err != nil || value != 3 {
+		t.Fatalf("expecting PeekBack 3, got %v, %v", err, value)
+	}
+	if err, value := deque.PopBack(); err != nil || value != 3 {
+		t.Fatalf("expecting PopBack 3, got %v, %v", err, value)
+	}
+	if err, value := deque.Poll(); err != nil || value != 1 {
+		t.Fatalf("expecting Poll 1, got %v, %v", err, value)
+	}
+	expectDeque(t, deque, 2)
+
+	_, _ = deque.Poll()
+	expectDeque(t, deque)
+}
+
+func Test_DequeListIterator(t *testing.T) {
+	deque := newLinkedDeque()
+	for i := 1; i <= 5; i++ {
+		deque.Push(i)
+	}
+
+	iter := deque.ListIterator()
+	if err := iter.Remove(); err != ErrIllegalIteratorState {
+		t.Fatalf("expecting ErrIllegalIteratorState, got %v", err)
+	}
+
+	// 删除偶数, 奇数乘以 10
+	for iter.More() {
+		err, value := iter.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value.(int)%2 == 0 {
+			err = iter.Remove()
+		} else {
+			err = iter.Set(value.(int) * 10)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectDeque(t, deque, 10, 30, 50)
+
+	if err := iter.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := iter.Remove(); err != ErrIllegalIteratorState {
+		t.Fatalf("expecting ErrIllegalIteratorState, got %v", err)
+	}
+	expectDeque(t, deque, 10, 30)
+
+	iter = deque.ListIterator()
+	_, _ = iter.Next()
+	_ = iter.InsertBefore(5)
+	_ = iter.InsertAfter(15)
+	if err, value := iter.Next(); err != nil || value != 30 {
+		t.Fatalf("expecting cursor not moved by inserts, got %v, %v", err, value)
+	}
+	if err, value := iter.Previous(); err != nil || value != 30 {
+		t.Fatalf("expecting previous 30, got %v, %v", err, value)
+	}
+	if err := iter.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err, value := iter.Previous(); err != nil || value != 15 {
+		t.Fatalf("expecting previous 15, got %v, %v", err, value)
+	}
+	expectDeque(t, deque, 5, 10, 15)
+
+	// 绕过迭代器修改双端队列, 迭代器快速失败
+	iter = deque.ListIterator()
+	deque.PushFront(0)
+	if err, _ := iter.Next(); err != ErrConcurrentModification {
+		t.Fatalf("expecting ErrConcurrentModification, got %v", err)
+	}
+	if iter.More() {
+		t.Fatal("expecting iter.More() false after concurrent modification")
+	}
+	if err := iter.Set(1); err != ErrConcurrentModification {
+		t.Fatalf("expecting ErrConcurrentModification, got %v", err)
+	}
+}
@@ -0,0 +1,696 @@
+package iterator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCorruptSegment   = errors.New("corrupt segment")
+	ErrSegmentCompacted = errors.New("segment compacted")
+)
+
+// 元素序列化接口
+type ICodec interface {
+	Encode(it interface{}) (error, []byte)
+	Decode(data []byte) (error, interface{})
+}
+
+// 基于 gob 的默认编码, 自定义类型需先调用 gob.Register 注册
+type gobCodec struct {
+}
+
+func newGobCodec() ICodec {
+	return &gobCodec{}
+}
+
+func (g *gobCodec) Encode(it interface{}) (error, []byte) {
+	b := bytes.Buffer{}
+	if err := gob.NewEncoder(&b).Encode(&it); err != nil {
+		return err, nil
+	}
+	return nil, b.Bytes()
+}
+
+func (g *gobCodec) Decode(data []byte) (error, interface{}) {
+	var it interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&it); err != nil {
+		return err, nil
+	}
+	return nil, it
+}
+
+// 落盘策略
+type FsyncPolicy int
+
+const (
+	FsyncAlways FsyncPolicy = iota // 每次 Push/Poll 后 fsync
+	FsyncBatch                     // 每 FsyncBatch 次写入 fsync 一次
+	FsyncNever                     // 由操作系统决定何时落盘, 仅在 Sync()/Close() 时 fsync
+)
+
+type DiskQueueOptions struct {
+	SegmentSize int64 // 单个段文件的最大字节数, 超过后滚动到新段
+	Fsync       FsyncPolicy
+	FsyncBatch  int
+	Codec       ICodec
+}
+
+func defaultDiskQueueOptions() *DiskQueueOptions {
+	return &DiskQueueOptions{
+		SegmentSize: 64 << 20,
+		Fsync:       FsyncAlways,
+		FsyncBatch:  128,
+		Codec:       newGobCodec(),
+	}
+}
+
+// 磁盘持久化队列接口
+type IDurableQueue interface {
+	ILinkedList
+	// Push 没有返回值, 写入失败的原因通过 Err() 获取
+	Err() error
+	Sync() error
+	// 删除已被完全消费的段文件
+	Compact() error
+	Close() error
+}
+
+const (
+	segmentExt       = ".seg"
+	metaFileName     = "head.meta"
+	recordHeaderSize = 8 // 4 字节长度 + 4 字节 crc32
+)
+
+// 段文件, 文件名为段内第一个元素的序号
+type diskSegment struct {
+	start uint64
+	path  string
+	size  int64
+}
+
+// 实现 IDurableQueue 接口, 元素以追加写的方式记录在段文件中, 消费位置记录在 head.meta 中
+type xDiskQueue struct {
+	dir      string
+	options  DiskQueueOptions
+	segments []*diskSegment
+	head     uint64 // 下一个待消费元素的序号
+	tail     uint64 // 下一个写入元素的序号
+	writer   *os.File
+	// 段文件与 head.meta 分别计数, 以免 Poll 触发的 fsync 抵消 Push 的计数
+	unsyncedWrites int
+	unsyncedPolls  int
+
+	reader     *os.File // head 所在段, 为 nil 时在下次 Poll 时重新定位
+	readIndex  int
+	readOffset int64
+	modCount   int
+	err        error
+	closed     bool
+}
+
+// 打开 dir 下的队列, 并重放段文件恢复状态; options 为 nil 时使用默认配置
+func newDiskQueue(dir string, options *DiskQueueOptions) (error, IDurableQueue) {
+	opts := defaultDiskQueueOptions()
+	if options != nil {
+		if options.SegmentSize > 0 {
+			opts.SegmentSize = options.SegmentSize
+		}
+		if options.FsyncBatch > 0 {
+			opts.FsyncBatch = options.FsyncBatch
+		}
+		if options.Codec != nil {
+			opts.Codec = options.Codec
+		}
+		opts.Fsync = options.Fsync
+	}
+
+	q := &xDiskQueue{
+		dir:     dir,
+		options: *opts,
+	}
+	if err := q.recover(); err != nil {
+		return err, nil
+	}
+	return nil, q
+}
+
+func segmentPath(dir string, start uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", start, segmentExt))
+}
+
+// 扫描段文件, 返回有效记录数与有效数据的结尾位置, 遇到不完整或校验失败的记录即停止
+func scanSegment(path string) (error, uint64, int64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return err, 0, 0, false
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return err, 0, 0, false
+	}
+
+	r := bufio.NewReader(f)
+	header := make([]byte, recordHeaderSize)
+	count, offset := uint64(0), int64(0)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil, count, offset, false
+			}
+			if err == io.ErrUnexpectedEOF {
+				return nil, count, offset, true
+			}
+			return err, 0, 0, false
+		}
+
+		// 长度损坏时不按其分配内存, 超出文件剩余部分的记录视为写了一半
+		length := binary.BigEndian.Uint32(header[0:4])
+		if int64(length) > info.Size()-offset-recordHeaderSize {
+			return nil, count, offset, true
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, count, offset, true
+			}
+			return err, 0, 0, false
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			return nil, count, offset, true
+		}
+
+		count++
+		offset += recordHeaderSize + int64(length)
+	}
+}
+
+// 读取 offset 处的记录, 返回记录内容与下一条记录的位置
+func readRecord(f *os.File, offset int64) (error, []byte, int64) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := f.ReadAt(header, offset); err != nil {
+		return err, nil, 0
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return err, nil, 0
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if int64(length) > info.Size()-offset-recordHeaderSize {
+		return fmt.Errorf("%w: record length %d exceeds file size", ErrCorruptSegment, length), nil, 0
+	}
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, offset+recordHeaderSize); err != nil {
+		return err, nil, 0
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return ErrCorruptSegment, nil, 0
+	}
+	return nil, payload, offset + recordHeaderSize + int64(length)
+}
+
+// 跳过 n 条记录, 返回之后的位置
+func skipRecords(f *os.File, offset int64, n uint64) (error, int64) {
+	header := make([]byte, recordHeaderSize)
+	for ; n > 0; n-- {
+		if _, err := f.ReadAt(header, offset); err != nil {
+			return err, 0
+		}
+		offset += recordHeaderSize + int64(binary.BigEndian.Uint32(header[0:4]))
+	}
+	return nil, offset
+}
+
+func (q *xDiskQueue) recover() error {
+	if err := os.MkdirAll(q.dir, 0755); err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+	for _, it := range files {
+		name := it.Name()
+		if it.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		start, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, &diskSegment{
+			start: start,
+			path:  filepath.Join(q.dir, name),
+		})
+	}
+	sort.Slice(q.segments, func(i, j int) bool {
+		return q.segments[i].start < q.segments[j].start
+	})
+
+	err, head, hasMeta := q.readMeta()
+	if err != nil {
+		return err
+	}
+
+	if len(q.segments) == 0 {
+		seg := &diskSegment{start: head, path: segmentPath(q.dir, head)}
+		q.segments = append(q.segments, seg)
+	}
+
+	// 重放段文件: 中间段必须完整, 最后一段截断掉崩溃时写了一半的记录
+	for i, seg := range q.segments {
+		err, count, size, corrupt := scanSegment(seg.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		last := i == len(q.segments)-1
+		if !last && (corrupt || seg.start+count != q.segments[i+1].start) {
+			return fmt.Errorf("%w: %s", ErrCorruptSegment, seg.path)
+		}
+		if last {
+			if corrupt {
+				if err := os.Truncate(seg.path, size); err != nil {
+					return err
+				}
+			}
+			q.tail = seg.start + count
+		}
+		seg.size = size
+	}
+
+	q.head = q.segments[0].start
+	if hasMeta && head > q.head {
+		q.head = head
+	}
+	if q.head > q.tail {
+		q.head = q.tail
+	}
+
+	active := q.segments[len(q.segments)-1]
+	q.writer, err = os.OpenFile(active.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	return q.syncDir()
+}
+
+func (q *xDiskQueue) readMeta() (error, uint64, bool) {
+	data, err := ioutil.ReadFile(filepath.Join(q.dir, metaFileName))
+	if os.IsNotExist(err) {
+		return nil, 0, false
+	}
+	if err != nil {
+		return err, 0, false
+	}
+	if len(data) != 8 {
+		return fmt.Errorf("%w: %s", ErrCorruptSegment, metaFileName), 0, false
+	}
+	return nil, binary.BigEndian.Uint64(data), true
+}
+
+// 通过临时文件 + rename 原子地更新消费位置
+func (q *xDiskQueue) writeMeta(sync bool) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, q.head)
+
+	path := filepath.Join(q.dir, metaFileName)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil && sync {
+		err = syncFile(f)
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	if sync {
+		return q.syncDir()
+	}
+	return nil
+}
+
+// 测试中替换以统计 fsync 的调用
+var syncFile = func(f *os.File) error {
+	return f.Sync()
+}
+
+// rename 与新建的段文件需要 fsync 所在目录才能在断电后保留
+func (q *xDiskQueue) syncDir() error {
+	dir, err := os.Open(q.dir)
+	if err != nil {
+		return err
+	}
+	err = syncFile(dir)
+	if e := dir.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// 按落盘策略决定本次写入后是否 fsync, counter 为对应文件未落盘的写入次数
+func (q *xDiskQueue) shouldSync(counter *int) bool {
+	switch q.options.Fsync {
+	case FsyncAlways:
+		return true
+	case FsyncBatch:
+		*counter++
+		if *counter >= q.options.FsyncBatch {
+			*counter = 0
+			return true
+		}
+	}
+	return false
+}
+
+// 查找包含 seq 的段, 返回段下标与段内的位置
+func (q *xDiskQueue) locate(seq uint64) (error, int, *os.File, int64) {
+	index := sort.Search(len(q.segments), func(i int) bool {
+		return q.segments[i].start > seq
+	}) - 1
+	if index < 0 {
+		return ErrSegmentCompacted, 0, nil, 0
+	}
+
+	seg := q.segments[index]
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err, 0, nil, 0
+	}
+	err, offset := skipRecords(f, 0, seq-seg.start)
+	if err != nil {
+		_ = f.Close()
+		return err, 0, nil, 0
+	}
+	return nil, index, f, offset
+}
+
+// 段的结尾序号, 最后一段没有上界
+func (q *xDiskQueue) segmentEnd(index int) uint64 {
+	if index+1 < len(q.segments) {
+		return q.segments[index+1].start
+	}
+	return math.MaxUint64
+}
+
+func (q *xDiskQueue) closeReader() {
+	if q.reader != nil {
+		_ = q.reader.Close()
+		q.reader = nil
+	}
+}
+
+// 当前活跃段写满后, 以 tail 为起点创建新段
+func (q *xDiskQueue) roll() error {
+	if err := syncFile(q.writer); err != nil {
+		return err
+	}
+	if err := q.writer.Close(); err != nil {
+		return err
+	}
+
+	seg := &diskSegment{start: q.tail, path: segmentPath(q.dir, q.tail)}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	q.writer = f
+	q.segments = append(q.segments, seg)
+	q.unsyncedWrites = 0
+	return q.syncDir()
+}
+
+func (q *xDiskQueue) Size() int {
+	return int(q.tail - q.head)
+}
+
+func (q *xDiskQueue) Err() error {
+	return q.err
+}
+
+func (q *xDiskQueue) Push(it interface{}) {
+	if err := q.push(it); err != nil {
+		q.err = err
+	}
+}
+
+func (q *xDiskQueue) push(it interface{}) error {
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	err, payload := q.options.Codec.Encode(it)
+	if err != nil {
+		return err
+	}
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+
+	active := q.segments[len(q.segments)-1]
+	if active.size > 0 && active.size+int64(len(record)) > q.options.SegmentSize {
+		if err := q.roll(); err != nil {
+			return err
+		}
+		active = q.segments[len(q.segments)-1]
+	}
+
+	if _, err := q.writer.Write(record); err != nil {
+		// 回滚写了一半的记录, 避免后续写入在恢复时被当作损坏数据截断
+		_ = q.writer.Truncate(active.size)
+		return err
+	}
+	active.size += int64(len(record))
+	q.tail++
+	q.modCount++
+
+	if q.shouldSync(&q.unsyncedWrites) {
+		return syncFile(q.writer)
+	}
+	return nil
+}
+
+func (q *xDiskQueue) Poll() (error, interface{}) {
+	if q.closed {
+		return ErrQueueClosed, nil
+	}
+	if q.head >= q.tail {
+		return ErrEmptyList, nil
+	}
+
+	if q.reader != nil && q.head >= q.segmentEnd(q.readIndex) {
+		// head 已越过当前段, 前面的段均已消费完毕
+		q.closeReader()
+		if err := q.compact(); err != nil {
+			return err, nil
+		}
+	}
+	if q.reader == nil {
+		err, index, f, offset := q.locate(q.head)
+		if err != nil {
+			return err, nil
+		}
+		q.reader, q.readIndex, q.readOffset = f, index, offset
+	}
+
+	err, payload, next := readRecord(q.reader, q.readOffset)
+	if err != nil {
+		return err, nil
+	}
+	err, it := q.options.Codec.Decode(payload)
+	if err != nil {
+		return err, nil
+	}
+
+	q.readOffset = next
+	q.head++
+	q.modCount++
+	if err := q.writeMeta(q.shouldSync(&q.unsyncedPolls)); err != nil {
+		return err, nil
+	}
+	return nil, it
+}
+
+func (q *xDiskQueue) Compact() error {
+	if q.closed {
+		return ErrQueueClosed
+	}
+	return q.compact()
+}
+
+func (q *xDiskQueue) compact() error {
+	// 队列已被消费完时滚动出一个空段, 使最后一个段也可以被删除
+	if q.head == q.tail && q.segments[len(q.segments)-1].size > 0 {
+		if err := q.roll(); err != nil {
+			return err
+		}
+	}
+
+	removed := 0
+	for len(q.segments) > 1 && q.segments[1].start <= q.head {
+		if err := os.Remove(q.segments[0].path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		q.segments = q.segments[1:]
+		removed++
+	}
+
+	if removed > 0 {
+		if q.reader != nil && q.readIndex < removed {
+			q.closeReader()
+		}
+		q.readIndex -= removed
+	}
+	return nil
+}
+
+func (q *xDiskQueue) Sync() error {
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if err := syncFile(q.writer); err != nil {
+		return err
+	}
+	q.unsyncedWrites, q.unsyncedPolls = 0, 0
+	return q.writeMeta(true)
+}
+
+func (q *xDiskQueue) Close() error {
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	err := q.Sync()
+	if e := q.writer.Close(); err == nil {
+		err = e
+	}
+	q.closeReader()
+	q.closed = true
+	return err
+}
+
+// 遍历期间队列被 Push/Poll 则快速失败
+func (q *xDiskQueue) Iterator() IIterator {
+	return newDiskQueueIterator(q, true)
+}
+
+// 遍历创建时 [head, tail) 范围内的元素, 不受之后 Push/Poll 的影响, 但所在段被 Compact() 删除后返回 ErrSegmentCompacted
+func (q *xDiskQueue) SnapshotIterator() IIterator {
+	return newDiskQueueIterator(q, false)
+}
+
+// 按序号区间拆分, 拆分出的各部分可由不同 goroutine 并发读取, 遍历期间队列被修改则快速失败
+func (q *xDiskQueue) Spliterator() ISpliterator {
+	return newDiskQueueIterator(q, true)
+}
+
+// 磁盘队列迭代器, 实现 ISpliterator 接口, 逐条从段文件中读取元素, 不会把全部元素加载到内存
+type xDiskQueueIterator struct {
+	queue            *xDiskQueue
+	seq              uint64
+	end              uint64
+	file             *os.File
+	offset           int64
+	segmentEnd       uint64
+	failFast         bool
+	expectedModCount int
+}
+
+func newDiskQueueIterator(queue *xDiskQueue, failFast bool) *xDiskQueueIterator {
+	return &xDiskQueueIterator{
+		queue:            queue,
+		seq:              queue.head,
+		end:              queue.tail,
+		failFast:         failFast,
+		expectedModCount: queue.modCount,
+	}
+}
+
+func (d *xDiskQueueIterator) close() {
+	if d.file != nil {
+		_ = d.file.Close()
+		d.file = nil
+	}
+}
+
+func (d *xDiskQueueIterator) More() bool {
+	return d.seq < d.end && (!d.failFast || d.queue.modCount == d.expectedModCount)
+}
+
+func (d *xDiskQueueIterator) Next() (error, interface{}) {
+	if d.failFast && d.queue.modCount != d.expectedModCount {
+		return ErrConcurrentModification, nil
+	}
+	if d.seq >= d.end {
+		return ErrNoMoreElements, nil
+	}
+
+	if d.file == nil || d.seq >= d.segmentEnd {
+		d.close()
+		err, index, f, offset := d.queue.locate(d.seq)
+		if err != nil {
+			return err, nil
+		}
+		d.file, d.offset, d.segmentEnd = f, offset, d.queue.segmentEnd(index)
+	}
+
+	err, payload, next := readRecord(d.file, d.offset)
+	if err != nil {
+		return err, nil
+	}
+	d.offset = next
+	d.seq++
+	if d.seq >= d.end {
+		d.close()
+	}
+	return d.queue.options.Codec.Decode(payload)
+}
+
+func (d *xDiskQueueIterator) EstimateSize() int {
+	return int(d.end - d.seq)
+}
+
+func (d *xDiskQueueIterator) TrySplit() ISpliterator {
+	if d.end-d.seq < 2 {
+		return nil
+	}
+
+	mid := d.seq + (d.end-d.seq)/2
+	prefix := &xDiskQueueIterator{
+		queue:            d.queue,
+		seq:              d.seq,
+		end:              mid,
+		failFast:         d.failFast,
+		expectedModCount: d.expectedModCount,
+	}
+	// 已打开的段文件位置不再有效, 下次 Next() 时重新定位
+	d.close()
+	d.seq = mid
+	return prefix
+}
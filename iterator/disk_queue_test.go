@@ -0,0 +1,243 @@
+package iterator
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func openDiskQueue(t *testing.T, dir string, options *DiskQueueOptions) IDurableQueue {
+	t.Helper()
+	err, queue := newDiskQueue(dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queue
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func Test_DiskQueue(t *testing.T) {
+	dir := t.TempDir()
+	options := &DiskQueueOptions{SegmentSize: 64, Fsync: FsyncBatch, FsyncBatch: 4}
+
+	queue := openDiskQueue(t, dir, options)
+	for i := 0; i < 10; i++ {
+		queue.Push(i)
+	}
+	queue.Push("hello")
+	if queue.Err() != nil {
+		t.Fatal(queue.Err())
+	}
+	if queue.Size() != 11 {
+		t.Fatalf("expecting size 11, got %d", queue.Size())
+	}
+	if len(segmentFiles(t, dir)) < 2 {
+		t.Fatal("expecting segments to roll over")
+	}
+
+	for i := 0; i < 3; i++ {
+		if err, value := queue.Poll(); err != nil || value != i {
+			t.Fatalf("expecting poll %d, got %v, %v", i, err, value)
+		}
+	}
+	if err := queue.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err, _ := queue.Poll(); err != ErrQueueClosed {
+		t.Fatalf("expecting ErrQueueClosed, got %v", err)
+	}
+
+	// 重新打开后恢复未消费的元素
+	queue = openDiskQueue(t, dir, options)
+	if queue.Size() != 8 {
+		t.Fatalf("expecting size 8 after reopen, got %d", queue.Size())
+	}
+	err, items := Collect(queue.Iterator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{3, 4, 5, 6, 7, 8, 9, "hello"}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatalf("expecting %v, got %v", expected, items)
+	}
+
+	before := len(segmentFiles(t, dir))
+	for queue.Size() > 0 {
+		if err, _ := queue.Poll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err, _ := queue.Poll(); err != ErrEmptyList {
+		t.Fatalf("expecting ErrEmptyList, got %v", err)
+	}
+	if err := queue.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if after := len(segmentFiles(t, dir)); after != 1 || after >= before {
+		t.Fatalf("expecting compaction to leave 1 segment, got %d (was %d)", after, before)
+	}
+
+	queue.Push(42)
+	_ = queue.Close()
+	queue = openDiskQueue(t, dir, options)
+	if err, value := queue.Poll(); err != nil || value != 42 {
+		t.Fatalf("expecting poll 42 after compaction, got %v, %v", err, value)
+	}
+	_ = queue.Close()
+}
+
+func Test_DiskQueueCrashRecovery(t *testing.T) {
+	dir := t.TempDir()
+	queue := openDiskQueue(t, dir, nil)
+	queue.Push("a")
+	queue.Push("b")
+	_ = queue.Sync()
+
+	// 模拟崩溃时写了一半的记录
+	files := segmentFiles(t, dir)
+	f, err := os.OpenFile(files[len(files)-1], os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0, 0, 0, 100, 1, 2})
+	_ = f.Close()
+
+	queue = openDiskQueue(t, dir, nil)
+	queue.Push("c")
+	err, items := Collect(queue.SnapshotIterator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []interface{}{"a", "b", "c"}) {
+		t.Fatalf("expecting torn record to be truncated, got %v", items)
+	}
+	_ = queue.Close()
+
+	// 中间段损坏无法恢复
+	data, _ := ioutil.ReadFile(files[0])
+	data[len(data)-1] ^= 0xff
+	_ = ioutil.WriteFile(files[0], data, 0644)
+	_ = ioutil.WriteFile(segmentPath(dir, 100), nil, 0644)
+	if err, _ := newDiskQueue(dir, nil); err == nil {
+		t.Fatal("expecting error for corrupt middle segment")
+	}
+}
+
+func Test_DiskQueueCorruptLength(t *testing.T) {
+	dir := t.TempDir()
+	queue := openDiskQueue(t, dir, nil)
+	queue.Push("a")
+	_ = queue.Close()
+
+	// 长度字段损坏的完整记录头不会按其长度分配内存, 恢复时视为写了一半的记录
+	files := segmentFiles(t, dir)
+	f, err := os.OpenFile(files[0], os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0xff, 0xff, 0xff, 0xf0, 1, 2, 3, 4, 5})
+	if err, _, _ := readRecord(f, 0); err != nil {
+		t.Fatal(err)
+	}
+	err, size := skipRecords(f, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err, _, _ := readRecord(f, size); !errors.Is(err, ErrCorruptSegment) {
+		t.Fatalf("expecting ErrCorruptSegment, got %v", err)
+	}
+	_ = f.Close()
+
+	queue = openDiskQueue(t, dir, nil)
+	queue.Push("b")
+	err, items := Collect(queue.SnapshotIterator())
+	if err != nil || !reflect.DeepEqual(items, []interface{}{"a", "b"}) {
+		t.Fatalf("expecting corrupt record to be truncated, got %v, %v", items, err)
+	}
+	_ = queue.Close()
+}
+
+func Test_DiskQueueIterator(t *testing.T) {
+	dir := t.TempDir()
+	queue := openDiskQueue(t, dir, &DiskQueueOptions{SegmentSize: 64, Fsync: FsyncNever})
+	defer func() {
+		_ = queue.Close()
+	}()
+	for i := 0; i < 6; i++ {
+		queue.Push(i)
+	}
+
+	iter := queue.Iterator()
+	snapshot := queue.SnapshotIterator()
+	_, _ = iter.Next()
+	queue.Push(6)
+	if err, _ := iter.Next(); err != ErrConcurrentModification {
+		t.Fatalf("expecting ErrConcurrentModification, got %v", err)
+	}
+	if iter.More() {
+		t.Fatal("expecting iter.More() false after concurrent modification")
+	}
+
+	_, _ = queue.Poll()
+	err, items := Collect(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []interface{}{0, 1, 2, 3, 4, 5}) {
+		t.Fatalf("expecting snapshot of 6 items, got %v", items)
+	}
+
+	// 快照所在段被删除后返回 ErrSegmentCompacted
+	snapshot = queue.SnapshotIterator()
+	for queue.Size() > 0 {
+		_, _ = queue.Poll()
+	}
+	_ = queue.Compact()
+	if err, _ := snapshot.Next(); err != ErrSegmentCompacted {
+		t.Fatalf("expecting ErrSegmentCompacted, got %v", err)
+	}
+}
+
+func Test_DiskQueueFsyncBatch(t *testing.T) {
+	synced := make(map[string]int)
+	original := syncFile
+	defer func() {
+		syncFile = original
+	}()
+	syncFile = func(f *os.File) error {
+		synced[filepath.Ext(f.Name())]++
+		return f.Sync()
+	}
+
+	dir := t.TempDir()
+	queue := openDiskQueue(t, dir, &DiskQueueOptions{Fsync: FsyncBatch, FsyncBatch: 2})
+	delete(synced, "")
+
+	// Push 与 Poll 交替进行时, 段文件与 head.meta 各自按批次 fsync
+	for i := 0; i < 4; i++ {
+		queue.Push(i)
+		if err, _ := queue.Poll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := queue.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if synced[segmentExt] != 2 || synced[".tmp"] != 2 || synced[""] != 2 {
+		t.Fatalf("expecting 2 fsyncs of segment, meta and dir, got %v", synced)
+	}
+	if err := queue.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,6 +8,8 @@ type ILinkedList interface {
 	Push(it interface{})
 	Poll() (error, interface{})
 	Iterator() IIterator
+	SnapshotIterator() IIterator
+	Spliterator() ISpliterator
 }
 
 // 迭代器接口，More() 用于判断是否有更多的元素，Next() 用于获取下一个元素
@@ -16,11 +18,24 @@ type IIterator interface {
 	Next() (error, interface{})
 }
 
+// 可拆分迭代器接口, 用于并行遍历
+// TrySplit() 将剩余元素的前一半拆分给返回的迭代器, 自身保留后一半, 无法拆分时返回 nil
+// EstimateSize() 返回剩余元素数量的估计值
+type ISpliterator interface {
+	IIterator
+	TrySplit() ISpliterator
+	EstimateSize() int
+}
+
+// 遍历过程中集合被 Push/Poll 修改时, 迭代器 Next() 返回的错误
+var ErrConcurrentModification = errors.New("concurrent modification")
+
 // 实现 ILinkedList 接口
 type xLinkedList struct {
-	size int
-	head *xLinkedNode
-	tail *xLinkedNode
+	size     int
+	head     *xLinkedNode
+	tail     *xLinkedNode
+	modCount int // 结构性修改次数, 供迭代器检测并发修改
 }
 
 func newLinkedList() ILinkedList {
@@ -52,16 +67,18 @@ func (l *xLinkedList) Push(v interface{}) {
 		l.tail = node
 	}
 	l.size++
+	l.modCount++
 }
 
 func (l *xLinkedList) Poll() (error, interface{}) {
 	if l.size <= 0 {
-		return errors.New("empty list"), nil
+		return ErrEmptyList, nil
 	}
 
 	node := l.head
 	l.head = l.head.next
 	l.size--
+	l.modCount++
 	return nil, node.value
 }
 
@@ -69,30 +86,146 @@ func (l *xLinkedList) Iterator() IIterator {
 	return newLinkedListIterator(l)
 }
 
-// 队列迭代器，实现 IIterator 接口
+// 快照迭代器, 复制当前全部元素, 之后对队列的修改不影响遍历结果
+func (l *xLinkedList) SnapshotIterator() IIterator {
+	values := make([]interface{}, 0, l.size)
+	for node := l.head; node != nil; node = node.next {
+		values = append(values, node.value)
+	}
+	return newSliceIterator(values)
+}
+
+func (l *xLinkedList) Spliterator() ISpliterator {
+	return &xLinkedListSpliterator{
+		list:             l,
+		current:          l.head,
+		remaining:        l.size,
+		expectedModCount: l.modCount,
+	}
+}
+
+// 队列迭代器，实现 IIterator 接口, 遍历期间队列被修改则快速失败
 type xLinkedListIterator struct {
-	list    *xLinkedList
-	current *xLinkedNode
+	list             *xLinkedList
+	current          *xLinkedNode
+	expectedModCount int
 }
 
 func newLinkedListIterator(list *xLinkedList) IIterator {
 	return &xLinkedListIterator{
-		list:    list,
-		current: list.head,
+		list:             list,
+		current:          list.head,
+		expectedModCount: list.modCount,
 	}
 }
 
+// 队列被修改后迭代器失效, 不再有更多元素, 以免按 More()/Next() 循环时反复得到同一个错误
 func (l *xLinkedListIterator) More() bool {
-	return l.current != nil
+	return l.current != nil && l.list.modCount == l.expectedModCount
 }
 
 func (l *xLinkedListIterator) Next() (error, interface{}) {
+	if l.list.modCount != l.expectedModCount {
+		return ErrConcurrentModification, nil
+	}
+
 	node := l.current
 	if node == nil {
-		return errors.New("no more elements"), nil
+		return ErrNoMoreElements, nil
 	}
 
 	l.current = l.current.next
 	return nil, node.value
 }
 
+// 队列的可拆分迭代器, 实现 ISpliterator 接口, 遍历期间队列被修改则快速失败
+type xLinkedListSpliterator struct {
+	list             *xLinkedList
+	current          *xLinkedNode
+	remaining        int
+	expectedModCount int
+}
+
+func (l *xLinkedListSpliterator) More() bool {
+	return l.remaining > 0 && l.list.modCount == l.expectedModCount
+}
+
+func (l *xLinkedListSpliterator) Next() (error, interface{}) {
+	if l.list.modCount != l.expectedModCount {
+		return ErrConcurrentModification, nil
+	}
+	if l.remaining <= 0 {
+		return ErrNoMoreElements, nil
+	}
+
+	node := l.current
+	l.current = node.next
+	l.remaining--
+	return nil, node.value
+}
+
+func (l *xLinkedListSpliterator) EstimateSize() int {
+	return l.remaining
+}
+
+// 沿链表走过前一半节点, 前一半交给新的迭代器, 自身保留后一半
+func (l *xLinkedListSpliterator) TrySplit() ISpliterator {
+	if l.remaining < 2 {
+		return nil
+	}
+
+	half := l.remaining / 2
+	prefix := &xLinkedListSpliterator{
+		list:             l.list,
+		current:          l.current,
+		remaining:        half,
+		expectedModCount: l.expectedModCount,
+	}
+	for i := 0; i < half; i++ {
+		l.current = l.current.next
+	}
+	l.remaining -= half
+	return prefix
+}
+
+// 基于切片的迭代器, 实现 ISpliterator 接口
+type sliceIterator struct {
+	values []interface{}
+	index  int
+}
+
+func newSliceIterator(values []interface{}) ISpliterator {
+	return &sliceIterator{
+		values: values,
+	}
+}
+
+func (s *sliceIterator) More() bool {
+	return s.index < len(s.values)
+}
+
+func (s *sliceIterator) Next() (error, interface{}) {
+	if s.index >= len(s.values) {
+		return ErrNoMoreElements, nil
+	}
+
+	v := s.values[s.index]
+	s.index++
+	return nil, v
+}
+
+func (s *sliceIterator) EstimateSize() int {
+	return len(s.values) - s.index
+}
+
+func (s *sliceIterator) TrySplit() ISpliterator {
+	n := len(s.values) - s.index
+	if n < 2 {
+		return nil
+	}
+
+	mid := s.index + n/2
+	prefix := newSliceIterator(s.values[s.index:mid])
+	s.index = mid
+	return prefix
+}
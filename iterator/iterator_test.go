@@ -38,4 +38,55 @@ func Test_Iterator(t *testing.T) {
 			t.Log(value)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func Test_IteratorConcurrentModification(t *testing.T) {
+	queue := newLinkedList()
+	queue.Push(1)
+	queue.Push(2)
+	queue.Push(3)
+
+	iter := queue.Iterator()
+	if err, value := iter.Next(); err != nil || value != 1 {
+		t.Fatal("expecting iter.next 1")
+	}
+
+	queue.Push(4)
+	if err, _ := iter.Next(); err != ErrConcurrentModification {
+		t.Fatalf("expecting ErrConcurrentModification after push, got %v", err)
+	}
+	// 失效的迭代器不再有更多元素, 按 More()/Next() 循环时可以结束
+	if iter.More() {
+		t.Fatal("expecting iter.More() false after concurrent modification")
+	}
+	if err, _ := iter.Next(); err != ErrConcurrentModification {
+		t.Fatalf("expecting ErrConcurrentModification to persist, got %v", err)
+	}
+
+	iter = queue.Iterator()
+	_, _ = queue.Poll()
+	if err, _ := iter.Next(); err != ErrConcurrentModification {
+		t.Fatalf("expecting ErrConcurrentModification after poll, got %v", err)
+	}
+}
+
+func Test_SnapshotIterator(t *testing.T) {
+	queue := newLinkedList()
+	queue.Push(1)
+	queue.Push(2)
+
+	iter := queue.SnapshotIterator()
+	_, _ = queue.Poll()
+	queue.Push(3)
+
+	expected := []interface{}{1, 2}
+	for i := 0; iter.More(); i++ {
+		err, value := iter.Next()
+		if err != nil || value != expected[i] {
+			t.Fatalf("expecting snapshot[%d] == %v, got %v, %v", i, expected[i], err, value)
+		}
+	}
+	if err, _ := iter.Next(); err != ErrNoMoreElements {
+		t.Fatalf("expecting ErrNoMoreElements, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package iterator
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+var ErrOrderedForEach = errors.New("ordered for each is not supported")
+
+type FNConsumer func(v interface{}) error
+
+// 并行遍历的配置
+type ParallelOptions struct {
+	Parallelism int  // 并发 goroutine 数, <= 0 时使用 runtime.NumCPU()
+	Ordered     bool // MapReduce 是否按遍历顺序归约, ForEach 不支持
+}
+
+func (p *ParallelOptions) parallelism() int {
+	if p == nil || p.Parallelism <= 0 {
+		return runtime.NumCPU()
+	}
+	return p.Parallelism
+}
+
+// 将 src 反复对半拆分为至多 parallelism*4 块, 各块按原有顺序排列
+func splitChunks(src ISpliterator, parallelism int) []ISpliterator {
+	chunks := []ISpliterator{src}
+	for len(chunks) < parallelism*4 {
+		next := make([]ISpliterator, 0, len(chunks)*2)
+		for _, it := range chunks {
+			if it.EstimateSize() > 1 {
+				if prefix := it.TrySplit(); prefix != nil {
+					next = append(next, prefix)
+				}
+			}
+			next = append(next, it)
+		}
+		if len(next) == len(chunks) {
+			break
+		}
+		chunks = next
+	}
+	return chunks
+}
+
+// 由固定数量的 goroutine 逐块处理, 任一块出错即取消其余块并返回第一个错误
+func runChunks(ctx context.Context, chunks []ISpliterator, parallelism int, fn func(ctx context.Context, index int, chunk ISpliterator) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int, len(chunks))
+	for i := range chunks {
+		indexes <- i
+	}
+	close(indexes)
+
+	var once sync.Once
+	var first error
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism && w < len(chunks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					return
+				}
+				if err := fn(ctx, i, chunks[i]); err != nil {
+					once.Do(func() {
+						first = err
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if first != nil {
+		return first
+	}
+	return ctx.Err()
+}
+
+// 遍历一块元素, ctx 结束时提前返回
+func forEachInChunk(ctx context.Context, chunk ISpliterator, fn FNConsumer) error {
+	for chunk.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err, v := chunk.Next()
+		if err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 并行地对每个元素调用 fn, 调用顺序不确定, fn 需要是并发安全的
+// 需要按遍历顺序处理时请使用 ParallelMapReduce, Ordered 为 true 时返回 ErrOrderedForEach
+func ParallelForEach(ctx context.Context, src ISpliterator, options *ParallelOptions, fn FNConsumer) error {
+	if options != nil && options.Ordered {
+		return ErrOrderedForEach
+	}
+	parallelism := options.parallelism()
+	return runChunks(ctx, splitChunks(src, parallelism), parallelism, func(ctx context.Context, index int, chunk ISpliterator) error {
+		return forEachInChunk(ctx, chunk, fn)
+	})
+}
+
+// 并行地映射各元素并归约结果
+// reducer 需满足结合律, identity 为其单位元; 每块先各自归约, 再用 reducer 合并各块的结果
+// Ordered 为 true 时按遍历顺序合并, 否则按完成顺序合并(此时 reducer 还需满足交换律)
+func ParallelMapReduce(ctx context.Context, src ISpliterator, options *ParallelOptions, mapper FNMapper, identity interface{}, reducer FNReducer) (error, interface{}) {
+	parallelism := options.parallelism()
+	chunks := splitChunks(src, parallelism)
+	ordered := options != nil && options.Ordered
+
+	var mu sync.Mutex
+	partials := make([]interface{}, len(chunks))
+	result := identity
+	err := runChunks(ctx, chunks, parallelism, func(ctx context.Context, index int, chunk ISpliterator) error {
+		acc := identity
+		err := forEachInChunk(ctx, chunk, func(v interface{}) error {
+			acc = reducer(acc, mapper(v))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if ordered {
+			partials[index] = acc
+		} else {
+			result = reducer(result, acc)
+		}
+		return nil
+	})
+	if err != nil {
+		return err, nil
+	}
+
+	if ordered {
+		for _, it := range partials {
+			result = reducer(result, it)
+		}
+	}
+	return nil, result
+}
@@ -0,0 +1,129 @@
+package iterator
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_Spliterator(t *testing.T) {
+	queue := newLinkedList()
+	deque := newLinkedDeque()
+	err, disk := newDiskQueue(t.TempDir(), &DiskQueueOptions{SegmentSize: 128, Fsync: FsyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = disk.Close()
+	}()
+
+	for _, list := range []ILinkedList{queue, deque, disk} {
+		for i := 0; i < 7; i++ {
+			list.Push(i)
+		}
+
+		suffix := list.Spliterator()
+		prefix := suffix.TrySplit()
+		if prefix.EstimateSize() != 3 || suffix.EstimateSize() != 4 {
+			t.Fatalf("expecting split 3 + 4, got %d + %d", prefix.EstimateSize(), suffix.EstimateSize())
+		}
+		expectCollect(t, Chain(prefix, suffix), 0, 1, 2, 3, 4, 5, 6)
+
+		single := newTestList(1).Spliterator()
+		if single.TrySplit() != nil {
+			t.Fatal("expecting single element not to split")
+		}
+
+		iter := list.Spliterator()
+		list.Push(7)
+		if err, _ := iter.Next(); err != ErrConcurrentModification {
+			t.Fatalf("expecting ErrConcurrentModification, got %v", err)
+		}
+		if iter.More() {
+			t.Fatal("expecting iter.More() false after concurrent modification")
+		}
+	}
+}
+
+func Test_ParallelForEach(t *testing.T) {
+	queue := newLinkedList()
+	for i := 1; i <= 10000; i++ {
+		queue.Push(i)
+	}
+
+	var sum int64
+	err := ParallelForEach(context.Background(), queue.Spliterator(), &ParallelOptions{Parallelism: 4}, func(v interface{}) error {
+		atomic.AddInt64(&sum, int64(v.(int)))
+		return nil
+	})
+	if err != nil || sum != 50005000 {
+		t.Fatalf("expecting sum 50005000, got %v, %d", err, sum)
+	}
+
+	var visited int64
+	err = ParallelForEach(context.Background(), queue.Spliterator(), nil, func(v interface{}) error {
+		atomic.AddInt64(&visited, 1)
+		if v.(int) == 5000 {
+			return errTestFailure
+		}
+		return nil
+	})
+	if err != errTestFailure || visited >= 10000 {
+		t.Fatalf("expecting errTestFailure to stop early, got %v after %d items", err, visited)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ParallelForEach(ctx, queue.Spliterator(), nil, func(v interface{}) error {
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expecting context.Canceled, got %v", err)
+	}
+
+	visited = 0
+	err = ParallelForEach(context.Background(), queue.Spliterator(), &ParallelOptions{Ordered: true}, func(v interface{}) error {
+		atomic.AddInt64(&visited, 1)
+		return nil
+	})
+	if err != ErrOrderedForEach || visited != 0 {
+		t.Fatalf("expecting ErrOrderedForEach before visiting, got %v after %d items", err, visited)
+	}
+}
+
+func Test_ParallelMapReduce(t *testing.T) {
+	deque := newLinkedDeque()
+	expected := ""
+	for i := 0; i < 500; i++ {
+		deque.Push(i)
+		expected += strconv.Itoa(i) + ","
+	}
+
+	toString := func(v interface{}) interface{} {
+		return strconv.Itoa(v.(int)) + ","
+	}
+	concat := func(acc interface{}, v interface{}) interface{} {
+		return acc.(string) + v.(string)
+	}
+	err, result := ParallelMapReduce(context.Background(), deque.Spliterator(), &ParallelOptions{Parallelism: 8, Ordered: true}, toString, "", concat)
+	if err != nil || result != expected {
+		t.Fatalf("expecting ordered concatenation, got %v, %v", err, result)
+	}
+
+	square := func(v interface{}) interface{} {
+		return v.(int) * v.(int)
+	}
+	sum := func(acc interface{}, v interface{}) interface{} {
+		return acc.(int) + v.(int)
+	}
+	err, result = ParallelMapReduce(context.Background(), deque.Spliterator(), &ParallelOptions{Parallelism: 3}, square, 0, sum)
+	if err != nil || result != 41541750 {
+		t.Fatalf("expecting sum of squares 41541750, got %v, %v", err, result)
+	}
+
+	err, result = ParallelMapReduce(context.Background(), newLinkedList().Spliterator(), nil, square, 0, sum)
+	if err != nil || result != 0 {
+		t.Fatalf("expecting identity for empty list, got %v, %v", err, result)
+	}
+}
@@ -0,0 +1,280 @@
+package iterator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"reflect"
+)
+
+var ErrNotCollection = errors.New("value is not a slice, array, map or channel")
+
+// 将 *sql.Rows 的当前行映射为一个元素
+type FNScanRows func(rows *sql.Rows) (error, interface{})
+
+// 基于反射遍历切片或数组
+type xSliceValueIterator struct {
+	value reflect.Value
+	index int
+}
+
+// 将任意切片或数组包装为 IIterator
+func FromSlice(slice interface{}) (error, IIterator) {
+	if values, ok := slice.([]interface{}); ok {
+		return nil, newSliceIterator(values)
+	}
+
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return ErrNotCollection, nil
+	}
+	return nil, &xSliceValueIterator{value: v}
+}
+
+func (s *xSliceValueIterator) More() bool {
+	return s.index < s.value.Len()
+}
+
+func (s *xSliceValueIterator) Next() (error, interface{}) {
+	if s.index >= s.value.Len() {
+		return ErrNoMoreElements, nil
+	}
+
+	v := s.value.Index(s.index).Interface()
+	s.index++
+	return nil, v
+}
+
+// 将 map 包装为 IIterator, 元素为 Pair{First: key, Second: value}, 遍历顺序不确定
+// 创建时即确定键集合, 之后新增的键不会被遍历, 已删除的键会被跳过
+type xMapIterator struct {
+	value reflect.Value
+	keys  []reflect.Value
+	index int
+}
+
+func FromMap(m interface{}) (error, IIterator) {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return ErrNotCollection, nil
+	}
+	return nil, &xMapIterator{
+		value: v,
+		keys:  v.MapKeys(),
+	}
+}
+
+func (m *xMapIterator) advance() {
+	for m.index < len(m.keys) && !m.value.MapIndex(m.keys[m.index]).IsValid() {
+		m.index++
+	}
+}
+
+func (m *xMapIterator) More() bool {
+	m.advance()
+	return m.index < len(m.keys)
+}
+
+func (m *xMapIterator) Next() (error, interface{}) {
+	if !m.More() {
+		return ErrNoMoreElements, nil
+	}
+
+	key := m.keys[m.index]
+	m.index++
+	return nil, Pair{First: key.Interface(), Second: m.value.MapIndex(key).Interface()}
+}
+
+// 将任意可接收的 channel 包装为 IIterator, More() 会阻塞直到收到元素或 channel 被关闭
+type xChannelIterator struct {
+	value reflect.Value
+	ready bool
+	item  interface{}
+	done  bool
+}
+
+func FromChannel(ch interface{}) (error, IIterator) {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+		return ErrNotCollection, nil
+	}
+	return nil, &xChannelIterator{value: v}
+}
+
+func (c *xChannelIterator) More() bool {
+	if !c.ready && !c.done {
+		v, ok := c.value.Recv()
+		if ok {
+			c.ready, c.item = true, v.Interface()
+		} else {
+			c.done = true
+		}
+	}
+	return c.ready
+}
+
+func (c *xChannelIterator) Next() (error, interface{}) {
+	if !c.More() {
+		return ErrNoMoreElements, nil
+	}
+
+	v := c.item
+	c.ready, c.item = false, nil
+	return nil, v
+}
+
+// 按分隔符切分 io.Reader 的迭代器, 元素为去掉分隔符后的 string
+type xReaderIterator struct {
+	scanner *bufio.Scanner
+	ready   bool
+	done    bool
+	err     error
+}
+
+// 按行切分, 同时去掉行尾的 \r
+func FromLines(r io.Reader) IIterator {
+	return &xReaderIterator{scanner: bufio.NewScanner(r)}
+}
+
+// 按单字节分隔符切分
+func FromReader(r io.Reader, delim byte) IIterator {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.IndexByte(data, delim); i >= 0 {
+			return i + 1, data[0:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	return &xReaderIterator{scanner: scanner}
+}
+
+func (r *xReaderIterator) More() bool {
+	if !r.ready && !r.done {
+		if r.scanner.Scan() {
+			r.ready = true
+		} else {
+			r.done = true
+			r.err = r.scanner.Err()
+		}
+	}
+	return r.ready || r.err != nil
+}
+
+func (r *xReaderIterator) Next() (error, interface{}) {
+	if !r.More() {
+		return ErrNoMoreElements, nil
+	}
+
+	if r.err != nil {
+		err := r.err
+		r.err = nil
+		return err, nil
+	}
+	r.ready = false
+	return nil, r.scanner.Text()
+}
+
+// 遍历 *sql.Rows 的迭代器, 遍历结束后自动关闭 rows
+type xRowsIterator struct {
+	rows  *sql.Rows
+	fn    FNScanRows
+	ready bool
+	done  bool
+	err   error
+}
+
+// fn 为 nil 时, 每行映射为 []interface{}
+func FromRows(rows *sql.Rows, fn FNScanRows) IIterator {
+	if fn == nil {
+		fn = scanRowValues
+	}
+	return &xRowsIterator{
+		rows: rows,
+		fn:   fn,
+	}
+}
+
+func scanRowValues(rows *sql.Rows) (error, interface{}) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err, nil
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return err, nil
+	}
+	return nil, values
+}
+
+func (r *xRowsIterator) More() bool {
+	if !r.ready && !r.done {
+		if r.rows.Next() {
+			r.ready = true
+		} else {
+			r.done = true
+			r.err = r.rows.Err()
+			_ = r.rows.Close()
+		}
+	}
+	return r.ready || r.err != nil
+}
+
+func (r *xRowsIterator) Next() (error, interface{}) {
+	if !r.More() {
+		return ErrNoMoreElements, nil
+	}
+
+	if r.err != nil {
+		err := r.err
+		r.err = nil
+		return err, nil
+	}
+	r.ready = false
+	return r.fn(r.rows)
+}
+
+// 将迭代器的元素依次发送到 ch, 遇到第一个错误或 ctx 结束时返回, 不会关闭 ch
+func Drain(ctx context.Context, it IIterator, ch chan<- interface{}) error {
+	for it.More() {
+		err, v := it.Next()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case ch <- v:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// 在新的 goroutine 中把迭代器的元素发送到返回的 channel, 结束后关闭该 channel
+// 错误 channel 最多收到一个错误, 随后也会被关闭
+func ToChannel(ctx context.Context, it IIterator, buffer int) (<-chan interface{}, <-chan error) {
+	ch := make(chan interface{}, buffer)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(ch)
+		if err := Drain(ctx, it, ch); err != nil {
+			errs <- err
+		}
+	}()
+	return ch, errs
+}
@@ -0,0 +1,149 @@
+package iterator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func Test_SourceCollections(t *testing.T) {
+	err, it := FromSlice([]int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCollect(t, it, 1, 2, 3)
+
+	err, it = FromSlice([2]string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCollect(t, it, "a", "b")
+
+	if err, _ := FromSlice(1); err != ErrNotCollection {
+		t.Fatalf("expecting ErrNotCollection, got %v", err)
+	}
+
+	err, it = FromMap(map[string]int{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err, items := Collect(it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].(Pair).First.(string) < items[j].(Pair).First.(string)
+	})
+	expected := []interface{}{Pair{First: "a", Second: 1}, Pair{First: "b", Second: 2}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatalf("expecting %v, got %v", expected, items)
+	}
+
+	ch := make(chan string, 3)
+	ch <- "x"
+	ch <- "y"
+	close(ch)
+	err, it = FromChannel(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCollect(t, it, "x", "y")
+
+	if err, _ := FromChannel(make(chan<- int)); err != ErrNotCollection {
+		t.Fatalf("expecting ErrNotCollection for send-only channel, got %v", err)
+	}
+}
+
+type failingReader struct {
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	return 0, errTestFailure
+}
+
+func Test_SourceReader(t *testing.T) {
+	expectCollect(t, FromLines(strings.NewReader("a\r\nb\n\nc")), "a", "b", "", "c")
+	expectCollect(t, FromReader(strings.NewReader("1,2,,3,"), ','), "1", "2", "", "3")
+	expectCollect(t, FromLines(strings.NewReader("")))
+
+	if err, _ := Collect(FromLines(&failingReader{})); err != errTestFailure {
+		t.Fatalf("expecting errTestFailure, got %v", err)
+	}
+}
+
+func Test_SourceRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("mock error: '%s'", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	mock.ExpectQuery("select").WillReturnRows(
+		mock.NewRows([]string{"id", "name"}).AddRow(1, "John").AddRow(2, "Mike"))
+	rows, err := db.Query("select id,name from user_info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := FromRows(rows, func(rows *sql.Rows) (error, interface{}) {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err, nil
+		}
+		return nil, name
+	})
+	expectCollect(t, it, "John", "Mike")
+
+	mock.ExpectQuery("select").WillReturnRows(
+		mock.NewRows([]string{"id", "name"}).AddRow(1, "John").RowError(0, errors.New("row error")))
+	rows, err = db.Query("select id,name from user_info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err, _ := Collect(FromRows(rows, nil)); err == nil || err.Error() != "row error" {
+		t.Fatalf("expecting row error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_SourceToChannel(t *testing.T) {
+	ch, errs := ToChannel(context.Background(), newTestList(1, 2, 3).Iterator(), 0)
+	err, it := FromChannel(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCollect(t, Map(it, func(v interface{}) interface{} {
+		return v.(int) * 2
+	}), 2, 4, 6)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	// 无限生成器在 ctx 取消后停止
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, errs = ToChannel(ctx, naturals(), 0)
+	<-ch
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("expecting context.Canceled, got %v", err)
+	}
+
+	out := make(chan interface{}, 3)
+	if err := Drain(context.Background(), &failingIterator{src: newTestList(1, 2).Iterator(), failAt: 2}, out); err != errTestFailure {
+		t.Fatalf("expecting errTestFailure, got %v", err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("expecting 1 item drained before error, got %d", len(out))
+	}
+}
@@ -0,0 +1,386 @@
+package singleton
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrDuplicateType      = errors.New("duplicate bean type")
+	ErrUnknownType        = errors.New("unknown bean type")
+	ErrUnknownProfile     = errors.New("unknown profile")
+	ErrUnknownFormat      = errors.New("unknown config format")
+	ErrMissingEnv         = errors.New("environment variable is not set")
+	ErrUnknownProperty    = errors.New("unknown property")
+	ErrMissingProperty    = errors.New("missing required property")
+	ErrInvalidProperty    = errors.New("invalid property value")
+	ErrInvalidConfig      = errors.New("invalid config")
+	ErrInvalidPropertyTag = errors.New("invalid property tag")
+)
+
+const propertyTag = "property"
+
+// 未指定 profile 时从该环境变量读取, 多个 profile 以逗号分隔
+const ProfilesEnv = "BEAN_PROFILES_ACTIVE"
+
+// 配置文件格式
+type ConfigFormat string
+
+const (
+	ConfigJSON ConfigFormat = "json"
+	ConfigYAML ConfigFormat = "yaml"
+)
+
+// bean的配置, Type 为通过 RegisterType 注册的实现名称
+type BeanConfig struct {
+	Type       string                 `json:"type"`
+	Scope      string                 `json:"scope"`
+	Primary    *bool                  `json:"primary"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type ProfileConfig struct {
+	Beans map[string]*BeanConfig `json:"beans"`
+}
+
+// 配置文件的结构, profiles 中的bean覆盖 beans 中的同名bean, 实现不变时属性按键合并
+//
+//	beans:
+//	  userRepository:
+//	    type: mysqlRepository
+//	    properties:
+//	      dsn: ${DB_DSN:root@tcp(localhost:3306)/app}
+//	profiles:
+//	  test:
+//	    beans:
+//	      userRepository:
+//	        type: mockRepository
+type ContainerConfig struct {
+	Beans    map[string]*BeanConfig    `json:"beans"`
+	Profiles map[string]*ProfileConfig `json:"profiles"`
+}
+
+// 解析配置, 字符串中的 ${NAME} 与 ${NAME:default} 替换为环境变量的值
+func ParseConfig(data []byte, format ConfigFormat) (error, *ContainerConfig) {
+	var tree interface{}
+	switch format {
+	case ConfigJSON:
+		if err := json.Unmarshal(data, &tree); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConfig, err), nil
+		}
+	case ConfigYAML:
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConfig, err), nil
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format), nil
+	}
+
+	err, tree := interpolateEnv(tree)
+	if err != nil {
+		return err, nil
+	}
+
+	// 统一转换为 JSON 后解码, 以便拒绝未知的字段
+	data, err = json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err), nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	config := &ContainerConfig{}
+	if err := decoder.Decode(config); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err), nil
+	}
+	return nil, config
+}
+
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::([^}]*))?\}`)
+
+func interpolateEnv(tree interface{}) (error, interface{}) {
+	switch it := tree.(type) {
+	case string:
+		var err error
+		value := envPattern.ReplaceAllStringFunc(it, func(match string) string {
+			groups := envPattern.FindStringSubmatch(match)
+			if value, ok := os.LookupEnv(groups[1]); ok {
+				return value
+			}
+			if strings.Contains(match, ":") {
+				return groups[2]
+			}
+			if err == nil {
+				err = fmt.Errorf("%w: %s", ErrMissingEnv, groups[1])
+			}
+			return match
+		})
+		return err, value
+	case map[string]interface{}:
+		for k, v := range it {
+			err, value := interpolateEnv(v)
+			if err != nil {
+				return err, nil
+			}
+			it[k] = value
+		}
+	case []interface{}:
+		for i, v := range it {
+			err, value := interpolateEnv(v)
+			if err != nil {
+				return err, nil
+			}
+			it[i] = value
+		}
+	}
+	return nil, tree
+}
+
+// 合并 beans 与指定 profile 中的bean, 后指定的 profile 优先
+func (c *ContainerConfig) merge(profiles []string) (error, map[string]*BeanConfig) {
+	beans := make(map[string]*BeanConfig)
+	for name, it := range c.Beans {
+		if it == nil {
+			return fmt.Errorf("%w: bean %s is empty", ErrInvalidConfig, name), nil
+		}
+		beans[name] = it.clone()
+	}
+
+	for _, profile := range profiles {
+		p, ok := c.Profiles[profile]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownProfile, profile), nil
+		}
+		if p == nil {
+			continue
+		}
+		for name, it := range p.Beans {
+			if it == nil {
+				return fmt.Errorf("%w: bean %s in profile %s is empty", ErrInvalidConfig, name, profile), nil
+			}
+			// 替换实现时属性随之替换, 不再合并原实现的属性
+			base, ok := beans[name]
+			if !ok || (len(it.Type) > 0 && it.Type != base.Type) {
+				beans[name] = it.clone()
+				continue
+			}
+			if len(it.Scope) > 0 {
+				base.Scope = it.Scope
+			}
+			if it.Primary != nil {
+				base.Primary = it.Primary
+			}
+			for k, v := range it.Properties {
+				base.Properties[k] = v
+			}
+		}
+	}
+	return nil, beans
+}
+
+func (c *BeanConfig) clone() *BeanConfig {
+	it := *c
+	it.Properties = make(map[string]interface{}, len(c.Properties))
+	for k, v := range c.Properties {
+		it.Properties[k] = v
+	}
+	return &it
+}
+
+func parseScope(scope string) (error, Scope) {
+	for _, it := range []Scope{ScopeSingleton, ScopePrototype, ScopeRequest} {
+		if strings.EqualFold(scope, it.String()) {
+			return nil, it
+		}
+	}
+	if len(scope) == 0 {
+		return nil, ScopeSingleton
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidScope, scope), ScopeSingleton
+}
+
+// 注册配置文件中 type 对应的构造函数, 构造函数的形式与 Provide 相同
+// 构造函数可以接收一个带 property 标签的结构体参数, 由配置中的 properties 填充, 例如
+//
+//	type mysqlOptions struct {
+//		DSN      string `property:"dsn,required"`
+//		MaxConns int    `property:"maxConns"`
+//	}
+func (b *beanContainer) RegisterType(name string, constructor interface{}) error {
+	if err, _ := newBeanDefinition(constructor, BeanOptions{}); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.factories[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateType, name)
+	}
+	b.factories[name] = constructor
+	return nil
+}
+
+// 按配置注册bean, 未指定 profile 时使用环境变量 BEAN_PROFILES_ACTIVE 中的 profile
+func (b *beanContainer) ApplyConfig(config *ContainerConfig, profiles ...string) error {
+	if len(profiles) == 0 {
+		for _, it := range strings.Split(os.Getenv(ProfilesEnv), ",") {
+			if it = strings.TrimSpace(it); len(it) > 0 {
+				profiles = append(profiles, it)
+			}
+		}
+	}
+
+	err, beans := config.merge(profiles)
+	if err != nil {
+		return err
+	}
+
+	// 先创建全部bean的定义, 再在同一把锁内注册, 配置有误或与已有bean冲突时不注册任何bean
+	names := make([]string, 0, len(beans))
+	for name := range beans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defs := make([]*beanDefinition, 0, len(names))
+	for _, name := range names {
+		err, def := b.newConfiguredDefinition(name, beans[name])
+		if err != nil {
+			return fmt.Errorf("bean %s: %w", name, err)
+		}
+		defs = append(defs, def)
+	}
+	return b.register(defs...)
+}
+
+func (b *beanContainer) newConfiguredDefinition(name string, config *BeanConfig) (error, *beanDefinition) {
+	b.mu.RLock()
+	constructor, ok := b.factories[config.Type]
+	b.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownType, config.Type), nil
+	}
+
+	err, scope := parseScope(config.Scope)
+	if err != nil {
+		return err, nil
+	}
+	err, def := newBeanDefinition(constructor, BeanOptions{
+		Name:    name,
+		Scope:   scope,
+		Primary: config.Primary != nil && *config.Primary,
+	})
+	if err != nil {
+		return err, nil
+	}
+
+	// 配置中的属性必须被构造函数使用, 以便发现拼写错误
+	known := make(map[string]bool)
+	for _, param := range def.params {
+		for _, it := range param.properties {
+			known[it.key] = true
+		}
+	}
+	for key := range config.Properties {
+		if !known[key] {
+			return fmt.Errorf("%w: %s", ErrUnknownProperty, key), nil
+		}
+	}
+	def.properties = config.Properties
+	return nil, def
+}
+
+// 读取配置文件, 按扩展名 .json/.yaml/.yml 判断格式
+func (b *beanContainer) LoadConfigFile(path string, profiles ...string) error {
+	var format ConfigFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = ConfigJSON
+	case ".yaml", ".yml":
+		format = ConfigYAML
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	err, config := ParseConfig(data, format)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return b.ApplyConfig(config, profiles...)
+}
+
+type propertyField struct {
+	name     string
+	key      string
+	index    []int
+	required bool
+}
+
+// 解析结构体中带 property 标签的字段, 标签形如 `property:"dsn"` 或 `property:"dsn,required"`
+func propertyFields(t reflect.Type) (error, []propertyField) {
+	fields := make([]propertyField, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup(propertyTag)
+		if !ok {
+			continue
+		}
+		if f.PkgPath != "" {
+			return fmt.Errorf("%w: field %s.%s is unexported", ErrInvalidPropertyTag, t, f.Name), nil
+		}
+
+		parts := strings.Split(tag, ",")
+		field := propertyField{name: f.Name, key: strings.TrimSpace(parts[0]), index: f.Index}
+		if len(field.key) == 0 {
+			field.key = f.Name
+		}
+		for _, it := range parts[1:] {
+			if strings.TrimSpace(it) != "required" {
+				return fmt.Errorf("%w: unknown option %q on %s.%s", ErrInvalidPropertyTag, it, t, f.Name), nil
+			}
+			field.required = true
+		}
+		fields = append(fields, field)
+	}
+	return nil, fields
+}
+
+// 以 JSON 的规则转换属性值, 字符串形式的数字与布尔值(例如由环境变量替换得到的 "8080")也可以赋给对应类型的字段
+func applyProperties(target reflect.Value, fields []propertyField, properties map[string]interface{}) error {
+	for _, it := range fields {
+		value, ok := properties[it.key]
+		if !ok {
+			if it.required {
+				return fmt.Errorf("%w: %s", ErrMissingProperty, it.key)
+			}
+			continue
+		}
+
+		field := target.FieldByIndex(it.index)
+		data, err := json.Marshal(value)
+		if err == nil {
+			err = json.Unmarshal(data, field.Addr().Interface())
+		}
+		if s, ok := value.(string); ok && err != nil && field.Kind() != reflect.String {
+			err = json.Unmarshal([]byte(s), field.Addr().Interface())
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidProperty, it.key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package singleton
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type mysqlOptions struct {
+	DSN      string `property:"dsn,required"`
+	MaxConns int    `property:"maxConns"`
+	ReadOnly bool   `property:"readOnly"`
+}
+
+type mysqlSingleton struct {
+	options mysqlOptions
+}
+
+func (m *mysqlSingleton) Hello() {
+}
+
+type mockSingleton struct {
+}
+
+func (m *mockSingleton) Hello() {
+}
+
+const testConfigYAML = `
+beans:
+  store:
+    type: mysql
+    properties:
+      dsn: ${TEST_BEAN_DSN:root@tcp(localhost:3306)/app}
+      maxConns: ${TEST_BEAN_CONNS}
+profiles:
+  test:
+    beans:
+      store:
+        type: mock
+        properties: {}
+  prod:
+    beans:
+      store:
+        properties:
+          readOnly: true
+`
+
+const testConfigJSON = `{
+  "beans": {
+    "store": {
+      "type": "mysql",
+      "properties": {"dsn": "${TEST_BEAN_DSN:root@tcp(localhost:3306)/app}", "maxConns": "${TEST_BEAN_CONNS}"}
+    }
+  },
+  "profiles": {
+    "test": {"beans": {"store": {"type": "mock", "properties": {}}}},
+    "prod": {"beans": {"store": {"properties": {"readOnly": true}}}}
+  }
+}`
+
+func newConfigContainer(t *testing.T) *beanContainer {
+	container := newBeanContainer()
+	types := map[string]interface{}{
+		"mysql": func(options mysqlOptions) *mysqlSingleton { return &mysqlSingleton{options: options} },
+		"mock":  func() *mockSingleton { return &mockSingleton{} },
+	}
+	for name, constructor := range types {
+		if err := container.RegisterType(name, constructor); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return container
+}
+
+func Test_LoadConfigFile(t *testing.T) {
+	if err := os.Setenv("TEST_BEAN_CONNS", "16"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("TEST_BEAN_CONNS")
+
+	dir := t.TempDir()
+	for _, name := range []string{"beans.yaml", "beans.json"} {
+		path := filepath.Join(dir, name)
+		content := testConfigYAML
+		if filepath.Ext(name) == ".json" {
+			content = testConfigJSON
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// 同一份配置按 profile 装配不同的实现
+		container := newConfigContainer(t)
+		if err := container.LoadConfigFile(path, "prod"); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var it IDemoSingleton
+		if err := container.Resolve(&it); err != nil {
+			t.Fatal(err)
+		}
+		expected := mysqlOptions{DSN: "root@tcp(localhost:3306)/app", MaxConns: 16, ReadOnly: true}
+		if store, ok := it.(*mysqlSingleton); !ok || store.options != expected {
+			t.Fatalf("%s: expecting %+v, got %+v", name, expected, it)
+		}
+
+		container = newConfigContainer(t)
+		if err := os.Setenv(ProfilesEnv, "test"); err != nil {
+			t.Fatal(err)
+		}
+		err := container.LoadConfigFile(path)
+		_ = os.Unsetenv(ProfilesEnv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, it := container.GetBean("store"); !ok {
+			t.Fatal("expecting store bean")
+		} else if _, ok := it.(*mockSingleton); !ok {
+			t.Fatalf("%s: expecting mock for test profile, got %T", name, it)
+		}
+	}
+}
+
+func Test_ConfigError(t *testing.T) {
+	_ = os.Unsetenv("TEST_BEAN_CONNS")
+	if err, _ := ParseConfig([]byte(testConfigJSON), ConfigJSON); !errors.Is(err, ErrMissingEnv) {
+		t.Fatalf("expecting ErrMissingEnv, got %v", err)
+	}
+	if err, _ := ParseConfig([]byte(`{"beans": {"a": {"kind": "mysql"}}}`), ConfigJSON); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expecting ErrInvalidConfig for unknown field, got %v", err)
+	}
+	if err, _ := ParseConfig([]byte(`{}`), "toml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("expecting ErrUnknownFormat, got %v", err)
+	}
+
+	cases := []struct {
+		config   string
+		profiles []string
+		err      error
+	}{
+		{`{"beans": {"store": {"type": "mysql", "properties": {"dsn": "x"}}}}`, []string{"dev"}, ErrUnknownProfile},
+		{`{"beans": {"store": {"type": "redis"}}}`, nil, ErrUnknownType},
+		{`{"beans": {"store": {"type": "mysql", "properties": {"dsn": "x", "maxConn": 1}}}}`, nil, ErrUnknownProperty},
+		{`{"beans": {"store": {"type": "mysql", "scope": "session", "properties": {"dsn": "x"}}}}`, nil, ErrInvalidScope},
+	}
+	for _, it := range cases {
+		err, config := ParseConfig([]byte(it.config), ConfigJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := newConfigContainer(t).ApplyConfig(config, it.profiles...); !errors.Is(err, it.err) {
+			t.Fatalf("%s: expecting %v, got %v", it.config, it.err, err)
+		}
+	}
+
+	// 属性在创建bean时注入
+	for config, expected := range map[string]error{
+		`{"beans": {"store": {"type": "mysql"}}}`:                                                 ErrMissingProperty,
+		`{"beans": {"store": {"type": "mysql", "properties": {"dsn": "x", "maxConns": "many"}}}}`: ErrInvalidProperty,
+	} {
+		err, parsed := ParseConfig([]byte(config), ConfigJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		container := newConfigContainer(t)
+		if err := container.ApplyConfig(parsed); err != nil {
+			t.Fatal(err)
+		}
+		var store *mysqlSingleton
+		if err := container.Resolve(&store); !errors.Is(err, expected) {
+			t.Fatalf("%s: expecting %v, got %v", config, expected, err)
+		}
+	}
+
+	if err, _ := ParseConfig([]byte("beans: [a"), ConfigYAML); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expecting ErrInvalidConfig for malformed yaml, got %v", err)
+	}
+
+	// 任一bean注册失败时, 同一配置中的其他bean也不注册
+	err, parsed := ParseConfig([]byte(`{"beans": {"a": {"type": "mock"}, "b": {"type": "mock"}}}`), ConfigJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := newConfigContainer(t)
+	if err := container.SetBean("b", &mockSingleton{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.ApplyConfig(parsed); !errors.Is(err, ErrDuplicateBean) {
+		t.Fatalf("expecting ErrDuplicateBean, got %v", err)
+	}
+	if ok, _ := container.GetBean("a"); ok {
+		t.Fatal("expecting bean a not to be registered")
+	}
+
+	container = newConfigContainer(t)
+	if err := container.RegisterType("mock", func() *mockSingleton { return nil }); !errors.Is(err, ErrDuplicateType) {
+		t.Fatalf("expecting ErrDuplicateType, got %v", err)
+	}
+	if err := container.LoadConfigFile("beans.toml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("expecting ErrUnknownFormat, got %v", err)
+	}
+}
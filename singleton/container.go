@@ -0,0 +1,253 @@
+package singleton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrDuplicateBean      = errors.New("duplicate bean")
+	ErrBeanNotFound       = errors.New("bean not found")
+	ErrNilBean            = errors.New("bean is nil")
+	ErrInvalidConstructor = errors.New("constructor must be a func returning T or (error, T)")
+	ErrInvalidTarget      = errors.New("target must be a non-nil pointer")
+)
+
+// 依赖注入容器
+// 通过构造函数注册bean, 构造函数的参数按类型从容器中自动注入, bean在首次获取时创建且只创建一次
+type IBeanContainer interface {
+	IBeanController
+	// 注册构造函数, 以返回值的类型名作为bean的名称
+	// 构造函数形如 func(dep1 T1, dep2 T2) T 或 func(...) (error, T)
+	Provide(constructor interface{}) error
+	ProvideNamed(name string, constructor interface{}) error
+	ProvideWith(constructor interface{}, opts BeanOptions) error
+	// 按 target 指向的类型获取bean并写入 target, 例如 Resolve(&repo)
+	// target 为接口类型时匹配实现了该接口的bean, 存在多个时使用 Primary 的bean
+	Resolve(target interface{}) error
+	// 按名称获取bean并写入 target, 用于同一类型存在多个实现的情况
+	ResolveNamed(name string, target interface{}) error
+	// 按 inject 标签为结构体的字段注入bean
+	Inject(target interface{}) error
+	// 创建子作用域, 作用域内的 ScopeRequest bean在作用域结束时关闭
+	NewScope(ctx context.Context) IBeanScope
+	// 以 DOT 格式输出依赖关系图
+	WriteDOT(w io.Writer) error
+	// 注册配置文件中 type 对应的构造函数, 并按配置文件与 profile 注册bean
+	RegisterType(name string, constructor interface{}) error
+	ApplyConfig(config *ContainerConfig, profiles ...string) error
+	LoadConfigFile(path string, profiles ...string) error
+	IBeanLifecycle
+}
+
+// 注册bean的选项
+type BeanOptions struct {
+	Name    string // 为空时以返回值的类型名作为名称, 同一类型的多个实现以不同的名称区分
+	Scope   Scope
+	Primary bool // 按类型匹配到多个bean时优先使用
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// bean的定义, constructor 无效时表示通过 SetBean 注册的已创建的bean
+type beanDefinition struct {
+	name        string
+	scope       Scope
+	primary     bool
+	typ         reflect.Type
+	constructor reflect.Value
+	params      []beanParam
+	deps        []beanDependency       // 全部参数展开后的依赖
+	properties  map[string]interface{} // 由配置文件加载的属性, 注入到带 property 标签的结构体参数
+
+	mu       sync.Mutex // 保证bean只创建一次
+	built    bool
+	instance reflect.Value
+}
+
+func newBeanDefinition(constructor interface{}, opts BeanOptions) (error, *beanDefinition) {
+	fn := reflect.ValueOf(constructor)
+	if fn.Kind() != reflect.Func || fn.IsNil() || fn.Type().IsVariadic() {
+		return ErrInvalidConstructor, nil
+	}
+
+	t := fn.Type()
+	var typ reflect.Type
+	switch {
+	case t.NumOut() == 1 && t.Out(0) != errorType:
+		typ = t.Out(0)
+	case t.NumOut() == 2 && t.Out(0) == errorType && t.Out(1) != errorType:
+		typ = t.Out(1)
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidConstructor, t), nil
+	}
+
+	params := make([]beanParam, t.NumIn())
+	deps := make([]beanDependency, 0, t.NumIn())
+	for i := range params {
+		err, param := newBeanParam(t.In(i))
+		if err != nil {
+			return err, nil
+		}
+		params[i] = param
+		deps = append(deps, param.deps()...)
+	}
+	name := opts.Name
+	if len(name) == 0 {
+		name = typ.String()
+	}
+	return nil, &beanDefinition{
+		name:        name,
+		scope:       opts.Scope,
+		primary:     opts.Primary,
+		typ:         typ,
+		constructor: fn,
+		params:      params,
+		deps:        deps,
+	}
+}
+
+func (b *beanContainer) Provide(constructor interface{}) error {
+	return b.ProvideWith(constructor, BeanOptions{})
+}
+
+func (b *beanContainer) ProvideNamed(name string, constructor interface{}) error {
+	return b.ProvideWith(constructor, BeanOptions{Name: name})
+}
+
+func (b *beanContainer) ProvideWith(constructor interface{}, opts BeanOptions) error {
+	switch opts.Scope {
+	case ScopeSingleton, ScopePrototype, ScopeRequest:
+	default:
+		return fmt.Errorf("%w: %d", ErrInvalidScope, opts.Scope)
+	}
+
+	err, def := newBeanDefinition(constructor, opts)
+	if err != nil {
+		return err
+	}
+	return b.register(def)
+}
+
+// 在同一把锁内注册一组bean, 任一bean重名或形成循环依赖时整组都不注册
+func (b *beanContainer) register(defs ...*beanDefinition) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	registered := len(b.order)
+	rollback := func() {
+		for _, it := range b.order[registered:] {
+			delete(b.definitions, it.name)
+		}
+		b.order = b.order[:registered]
+	}
+
+	for _, def := range defs {
+		if b.started {
+			rollback()
+			return fmt.Errorf("%w: cannot register %s", ErrContainerStarted, def.name)
+		}
+		if _, ok := b.definitions[def.name]; ok {
+			rollback()
+			return fmt.Errorf("%w: name %s", ErrDuplicateBean, def.name)
+		}
+		b.definitions[def.name] = def
+		b.order = append(b.order, def)
+	}
+	for _, def := range defs {
+		if cycle := b.findCycle(def); len(cycle) > 0 {
+			rollback()
+			return fmt.Errorf("%w: %s", ErrCircularDependency, formatCycle(cycle))
+		}
+	}
+	return nil
+}
+
+func (b *beanContainer) Resolve(target interface{}) error {
+	return b.resolve(target, nil)
+}
+
+func (b *beanContainer) resolve(target interface{}, scope *beanScope) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ErrInvalidTarget
+	}
+
+	return b.resolveDependency(v.Elem(), beanDependency{typ: v.Type().Elem()}, scope)
+}
+
+func (b *beanContainer) getBean(name string, scope *beanScope) (bool, interface{}) {
+	b.mu.RLock()
+	def, ok := b.definitions[name]
+	b.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+
+	err, it := b.get(def, scope)
+	if err != nil {
+		return false, nil
+	}
+	return true, it.Interface()
+}
+
+// 按bean的作用域获取实例, scope 为 nil 表示在容器本身而不是子作用域中获取
+func (b *beanContainer) get(def *beanDefinition, scope *beanScope) (error, reflect.Value) {
+	switch def.scope {
+	case ScopePrototype:
+		return b.construct(def, scope)
+	case ScopeRequest:
+		if scope == nil {
+			return fmt.Errorf("%w: %s", ErrScopeRequired, def.name), reflect.Value{}
+		}
+		return scope.get(def)
+	}
+	return b.build(def)
+}
+
+// 创建单例bean及其依赖, 构造失败时不缓存结果, 下次获取时重新创建
+func (b *beanContainer) build(def *beanDefinition) (error, reflect.Value) {
+	def.mu.Lock()
+	defer def.mu.Unlock()
+
+	if def.built {
+		return nil, def.instance
+	}
+
+	err, instance := b.construct(def, nil)
+	if err != nil {
+		return err, reflect.Value{}
+	}
+	def.built = true
+	def.instance = instance
+	return nil, instance
+}
+
+// 注入依赖并调用构造函数
+// 单例bean在容器本身中获取依赖, 因此不能依赖作用域更短的 ScopeRequest bean
+func (b *beanContainer) construct(def *beanDefinition, scope *beanScope) (error, reflect.Value) {
+	for _, it := range def.deps {
+		err, dep := b.lookup(it)
+		if err == nil && def.scope == ScopeSingleton && dep.scope == ScopeRequest {
+			return fmt.Errorf("%w: singleton %s depends on %s", ErrScopeMismatch, def.name, dep.name), reflect.Value{}
+		}
+	}
+
+	args := make([]reflect.Value, len(def.params))
+	for i, it := range def.params {
+		args[i] = reflect.New(it.typ).Elem()
+		if err := b.injectParam(args[i], it, def.properties, scope); err != nil {
+			return fmt.Errorf("%s: %w", def.name, err), reflect.Value{}
+		}
+	}
+
+	out := def.constructor.Call(args)
+	if len(out) == 2 && !out[0].IsNil() {
+		return fmt.Errorf("%s: %w", def.name, out[0].Interface().(error)), reflect.Value{}
+	}
+	return nil, out[len(out)-1]
+}
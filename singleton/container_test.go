@@ -0,0 +1,142 @@
+package singleton
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type demoConfig struct {
+	dsn string
+}
+
+type demoRepository struct {
+	config *demoConfig
+}
+
+type demoService struct {
+	repo  *demoRepository
+	hello IDemoSingleton
+}
+
+// 各测试共用的注册函数, 注册失败时终止测试
+func mustProvide(t *testing.T, container IBeanContainer, constructor interface{}, opts BeanOptions) {
+	t.Helper()
+	if err := container.ProvideWith(constructor, opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_BeanContainer(t *testing.T) {
+	container := newBeanContainer()
+
+	var built int32
+	mustProvide(t, container, func(repo *demoRepository, hello IDemoSingleton) *demoService {
+		return &demoService{repo: repo, hello: hello}
+	}, BeanOptions{})
+	mustProvide(t, container, func(config *demoConfig) (error, *demoRepository) {
+		atomic.AddInt32(&built, 1)
+		return nil, &demoRepository{config: config}
+	}, BeanOptions{})
+	mustProvide(t, container, newContainedSingleton, BeanOptions{})
+	if err := container.SetBean("config", &demoConfig{dsn: "mysql://localhost"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发获取时bean只创建一次
+	services := make([]*demoService, 8)
+	errs := make([]error, len(services))
+	wg := sync.WaitGroup{}
+	for i := range services {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = container.Resolve(&services[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if built != 1 {
+		t.Fatalf("expecting repository to be built once, got %d", built)
+	}
+	for _, it := range services {
+		if it != services[0] || it.repo.config.dsn != "mysql://localhost" {
+			t.Fatalf("expecting the same service with injected config, got %+v", it)
+		}
+	}
+	services[0].hello.Hello()
+
+	if ok, it := container.GetBean("*singleton.demoService"); !ok || it != services[0] {
+		t.Fatalf("expecting bean by type name, got %v", it)
+	}
+	if ok, it := container.GetBean("config"); !ok || it.(*demoConfig).dsn != "mysql://localhost" {
+		t.Fatalf("expecting config bean, got %v", it)
+	}
+}
+
+func Test_BeanContainerError(t *testing.T) {
+	container := newBeanContainer()
+
+	if err := container.SetBean("config", &demoConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.SetBean("config", &demoConfig{}); !errors.Is(err, ErrDuplicateBean) {
+		t.Fatalf("expecting ErrDuplicateBean for duplicate name, got %v", err)
+	}
+	// 同一类型可以以不同的名称注册多个, 未命名时以类型名作为名称
+	if err := container.Provide(func() *demoConfig { return &demoConfig{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.Provide(func() *demoConfig { return &demoConfig{} }); !errors.Is(err, ErrDuplicateBean) {
+		t.Fatalf("expecting ErrDuplicateBean for duplicate type name, got %v", err)
+	}
+	if err := container.SetBean("nil", nil); !errors.Is(err, ErrNilBean) {
+		t.Fatalf("expecting ErrNilBean, got %v", err)
+	}
+
+	for _, it := range []interface{}{nil, 1, func() {}, func() error { return nil }, func() (*demoConfig, error) { return nil, nil }} {
+		if err := container.Provide(it); !errors.Is(err, ErrInvalidConstructor) {
+			t.Fatalf("expecting ErrInvalidConstructor for %T, got %v", it, err)
+		}
+	}
+
+	// 缺少依赖
+	if err := container.Provide(func(hello IDemoSingleton) *demoService { return &demoService{hello: hello} }); err != nil {
+		t.Fatal(err)
+	}
+	var service *demoService
+	if err := container.Resolve(&service); !errors.Is(err, ErrBeanNotFound) {
+		t.Fatalf("expecting ErrBeanNotFound, got %v", err)
+	}
+	if ok, _ := container.GetBean("*singleton.demoService"); ok {
+		t.Fatal("expecting GetBean to fail for missing dependency")
+	}
+	if err := container.Resolve(service); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("expecting ErrInvalidTarget, got %v", err)
+	}
+
+	// 构造失败不缓存, 下次获取时重新创建
+	fail := errors.New("connect refused")
+	attempts := 0
+	if err := container.Provide(func() (error, IDemoSingleton) {
+		attempts++
+		if attempts == 1 {
+			return fail, nil
+		}
+		return nil, newContainedSingleton()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.Resolve(&service); !errors.Is(err, fail) {
+		t.Fatalf("expecting constructor error, got %v", err)
+	}
+	if err := container.Resolve(&service); err != nil || service.hello == nil {
+		t.Fatalf("expecting retry to succeed, got %v", err)
+	}
+}
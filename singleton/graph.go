@@ -0,0 +1,89 @@
+package singleton
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+var ErrCircularDependency = errors.New("circular dependency")
+
+// 新注册的bean只会引入经过它自身的环, 因此只需从它出发查找
+// 返回环上的bean, 首尾均为 def, 不存在环时返回 nil, 调用方需持有 b.mu
+func (b *beanContainer) findCycle(def *beanDefinition) []*beanDefinition {
+	visited := make(map[*beanDefinition]bool)
+	path := make([]*beanDefinition, 0)
+
+	var visit func(current *beanDefinition) bool
+	visit = func(current *beanDefinition) bool {
+		path = append(path, current)
+		for _, it := range current.deps {
+			err, dep := b.lookupLocked(it)
+			if err != nil {
+				continue
+			}
+			if dep == def {
+				path = append(path, dep)
+				return true
+			}
+			if !visited[dep] {
+				visited[dep] = true
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+
+	if visit(def) {
+		return path
+	}
+	return nil
+}
+
+// 格式化为 A -> B -> C -> A
+func formatCycle(cycle []*beanDefinition) string {
+	names := make([]string, len(cycle))
+	for i, it := range cycle {
+		names[i] = it.name
+	}
+	return strings.Join(names, " -> ")
+}
+
+// 以 DOT 格式输出依赖关系图, 可用 graphviz 渲染, 例如 dot -Tpng
+// 节点标注bean的作用域, 缺失的依赖以红色虚线节点表示, 缺失的可选依赖不输出
+func (b *beanContainer) WriteDOT(w io.Writer) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	out := bufio.NewWriter(w)
+	_, _ = out.WriteString("digraph beans {\n")
+	for _, def := range b.order {
+		_, _ = out.WriteString("\t" + dotID(def.name) + " [label=" + dotID(def.name+`\n`+def.scope.String()) + "];\n")
+	}
+
+	missing := make(map[string]bool)
+	for _, def := range b.order {
+		for _, it := range def.deps {
+			target := it.String()
+			if err, dep := b.lookupLocked(it); err == nil {
+				target = dep.name
+			} else if it.optional {
+				continue
+			} else if !missing[target] {
+				missing[target] = true
+				_, _ = out.WriteString("\t" + dotID(target) + " [color=red, style=dashed];\n")
+			}
+			_, _ = out.WriteString("\t" + dotID(def.name) + " -> " + dotID(target) + ";\n")
+		}
+	}
+	_, _ = out.WriteString("}\n")
+	return out.Flush()
+}
+
+func dotID(id string) string {
+	return `"` + strings.ReplaceAll(id, `"`, `\"`) + `"`
+}
@@ -0,0 +1,65 @@
+package singleton
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type cycleA struct{}
+type cycleB struct{}
+type cycleC struct{}
+
+func Test_CircularDependency(t *testing.T) {
+	container := newBeanContainer()
+	if err := container.ProvideNamed("A", func(*cycleB) *cycleA { return &cycleA{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.ProvideNamed("B", func(*cycleC) *cycleB { return &cycleB{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	err := container.ProvideNamed("C", func(*cycleA) *cycleC { return &cycleC{} })
+	if !errors.Is(err, ErrCircularDependency) || !strings.HasSuffix(err.Error(), "C -> A -> B -> C") {
+		t.Fatalf("expecting cycle C -> A -> B -> C, got %v", err)
+	}
+	// 注册失败的bean不会留在容器中
+	if ok, _ := container.GetBean("C"); ok {
+		t.Fatal("expecting rejected bean not to be registered")
+	}
+	if err := container.ProvideNamed("C", func() *cycleC { return &cycleC{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	err = container.ProvideNamed("D", func(*demoConfig) *demoConfig { return nil })
+	if !errors.Is(err, ErrCircularDependency) || !strings.HasSuffix(err.Error(), "D -> D") {
+		t.Fatalf("expecting self cycle, got %v", err)
+	}
+}
+
+func Test_WriteDOT(t *testing.T) {
+	container := newBeanContainer()
+	if err := container.ProvideNamed("A", func(*cycleB, *demoConfig) *cycleA { return &cycleA{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.ProvideWith(func() *cycleB { return &cycleB{} }, BeanOptions{Name: "B", Scope: ScopePrototype}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	if err := container.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph beans {
+	"A" [label="A\nsingleton"];
+	"B" [label="B\nprototype"];
+	"A" -> "B";
+	"*singleton.demoConfig" [color=red, style=dashed];
+	"A" -> "*singleton.demoConfig";
+}
+`
+	if buf.String() != expected {
+		t.Fatalf("expecting %s, got %s", expected, buf.String())
+	}
+}
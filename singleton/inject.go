@@ -0,0 +1,231 @@
+package singleton
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrAmbiguousBean    = errors.New("ambiguous bean")
+	ErrBeanTypeMismatch = errors.New("bean type does not match")
+	ErrInvalidInjectTag = errors.New("invalid inject tag")
+)
+
+const injectTag = "inject"
+
+// bean的依赖, name 不为空时按名称(限定符)匹配, 否则按类型匹配
+// optional 的依赖不存在时保持零值
+type beanDependency struct {
+	typ      reflect.Type
+	name     string
+	optional bool
+}
+
+func (d beanDependency) String() string {
+	if len(d.name) > 0 {
+		return d.name
+	}
+	return d.typ.String()
+}
+
+type injectField struct {
+	name  string
+	index []int
+	dep   beanDependency
+}
+
+// 构造函数的参数, 带 inject 标签的结构体参数按字段注入, 带 property 标签的结构体参数由属性填充
+// 其他参数本身即为依赖
+type beanParam struct {
+	typ        reflect.Type
+	fields     []injectField
+	properties []propertyField
+}
+
+func newBeanParam(t reflect.Type) (error, beanParam) {
+	switch {
+	case t.Kind() == reflect.Struct && hasTag(t, injectTag):
+		err, fields := injectFields(t)
+		if err != nil {
+			return err, beanParam{}
+		}
+		return nil, beanParam{typ: t, fields: fields}
+	case t.Kind() == reflect.Struct && hasTag(t, propertyTag):
+		err, properties := propertyFields(t)
+		if err != nil {
+			return err, beanParam{}
+		}
+		return nil, beanParam{typ: t, properties: properties}
+	}
+	return nil, beanParam{typ: t}
+}
+
+func (p beanParam) deps() []beanDependency {
+	if p.properties != nil {
+		return nil
+	}
+	if p.fields == nil {
+		return []beanDependency{{typ: p.typ}}
+	}
+	deps := make([]beanDependency, len(p.fields))
+	for i, it := range p.fields {
+		deps[i] = it.dep
+	}
+	return deps
+}
+
+func hasTag(t reflect.Type, key string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析结构体中带 inject 标签的字段, 标签形如 `inject:""`、`inject:"name"` 或 `inject:"name,optional"`
+func injectFields(t reflect.Type) (error, []injectField) {
+	fields := make([]injectField, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup(injectTag)
+		if !ok {
+			continue
+		}
+		if f.PkgPath != "" {
+			return fmt.Errorf("%w: field %s.%s is unexported", ErrInvalidInjectTag, t, f.Name), nil
+		}
+
+		parts := strings.Split(tag, ",")
+		dep := beanDependency{typ: f.Type, name: strings.TrimSpace(parts[0])}
+		for _, it := range parts[1:] {
+			if strings.TrimSpace(it) != "optional" {
+				return fmt.Errorf("%w: unknown option %q on %s.%s", ErrInvalidInjectTag, it, t, f.Name), nil
+			}
+			dep.optional = true
+		}
+		fields = append(fields, injectField{name: f.Name, index: f.Index, dep: dep})
+	}
+	return nil, fields
+}
+
+func (b *beanContainer) lookup(dep beanDependency) (error, *beanDefinition) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.lookupLocked(dep)
+}
+
+// 按名称或类型查找bean, 接口类型匹配所有实现了该接口的bean
+// 匹配到多个bean时使用唯一的 Primary bean, 否则返回 ErrAmbiguousBean
+func (b *beanContainer) lookupLocked(dep beanDependency) (error, *beanDefinition) {
+	if len(dep.name) > 0 {
+		def, ok := b.definitions[dep.name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrBeanNotFound, dep.name), nil
+		}
+		if !def.typ.AssignableTo(dep.typ) {
+			return fmt.Errorf("%w: %s is %s, not %s", ErrBeanTypeMismatch, def.name, def.typ, dep.typ), nil
+		}
+		return nil, def
+	}
+
+	candidates := make([]*beanDefinition, 0)
+	primary := make([]*beanDefinition, 0)
+	for _, it := range b.order {
+		if it.typ.AssignableTo(dep.typ) {
+			candidates = append(candidates, it)
+			if it.primary {
+				primary = append(primary, it)
+			}
+		}
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return fmt.Errorf("%w: %s", ErrBeanNotFound, dep.typ), nil
+	case len(candidates) == 1:
+		return nil, candidates[0]
+	case len(primary) == 1:
+		return nil, primary[0]
+	}
+	names := make([]string, len(candidates))
+	for i, it := range candidates {
+		names[i] = it.name
+	}
+	return fmt.Errorf("%w: %s is provided by %s", ErrAmbiguousBean, dep.typ, strings.Join(names, ", ")), nil
+}
+
+// 获取依赖并写入 target
+func (b *beanContainer) resolveDependency(target reflect.Value, dep beanDependency, scope *beanScope) error {
+	err, def := b.lookup(dep)
+	if err != nil {
+		if dep.optional && errors.Is(err, ErrBeanNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	err, it := b.get(def, scope)
+	if err != nil {
+		return err
+	}
+	target.Set(it)
+	return nil
+}
+
+func (b *beanContainer) injectParam(target reflect.Value, param beanParam, properties map[string]interface{}, scope *beanScope) error {
+	if param.properties != nil {
+		return applyProperties(target, param.properties, properties)
+	}
+	if param.fields == nil {
+		return b.resolveDependency(target, beanDependency{typ: param.typ}, scope)
+	}
+	return b.injectInto(target, param.fields, scope)
+}
+
+func (b *beanContainer) injectInto(target reflect.Value, fields []injectField, scope *beanScope) error {
+	for _, it := range fields {
+		if err := b.resolveDependency(target.FieldByIndex(it.index), it.dep, scope); err != nil {
+			return fmt.Errorf("field %s: %w", it.name, err)
+		}
+	}
+	return nil
+}
+
+func (b *beanContainer) ResolveNamed(name string, target interface{}) error {
+	return b.resolveNamed(name, target, nil)
+}
+
+func (b *beanContainer) resolveNamed(name string, target interface{}, scope *beanScope) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ErrInvalidTarget
+	}
+	return b.resolveDependency(v.Elem(), beanDependency{typ: v.Type().Elem(), name: name}, scope)
+}
+
+// 为 target 指向的结构体中带 inject 标签的字段注入bean, 例如
+//
+//	type handler struct {
+//		Repo  IRepository `inject:"mysqlRepository"`
+//		Cache ICache      `inject:",optional"`
+//	}
+func (b *beanContainer) Inject(target interface{}) error {
+	return b.inject(target, nil)
+}
+
+func (b *beanContainer) inject(target interface{}, scope *beanScope) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidTarget
+	}
+
+	err, fields := injectFields(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return b.injectInto(v.Elem(), fields, scope)
+}
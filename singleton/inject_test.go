@@ -0,0 +1,112 @@
+package singleton
+
+import (
+	"errors"
+	"testing"
+)
+
+type greeter struct {
+	name string
+}
+
+func (g *greeter) Hello() {
+}
+
+type demoHandler struct {
+	Default IDemoSingleton  `inject:""`
+	Hungry  IDemoSingleton  `inject:"hungry"`
+	Config  *demoConfig     `inject:",optional"`
+	Missing *demoRepository `inject:"repo,optional"`
+	plain   int
+}
+
+func newInjectContainer(t *testing.T) *beanContainer {
+	container := newBeanContainer()
+	mustProvide(t, container, func() *greeter { return &greeter{name: "primary"} }, BeanOptions{Name: "greeter", Primary: true})
+	mustProvide(t, container, newContainedSingleton, BeanOptions{Name: "contained"})
+	if err := container.SetBean("hungry", gHungrySingleton); err != nil {
+		t.Fatal(err)
+	}
+	return container
+}
+
+func Test_ResolveByInterface(t *testing.T) {
+	container := newInjectContainer(t)
+
+	// 多个实现时使用 Primary 的bean
+	var it IDemoSingleton
+	if err := container.Resolve(&it); err != nil || it.(*greeter).name != "primary" {
+		t.Fatalf("expecting primary greeter, got %v %v", it, err)
+	}
+	if err := container.ResolveNamed("hungry", &it); err != nil || it != gHungrySingleton {
+		t.Fatalf("expecting hungry singleton, got %v %v", it, err)
+	}
+
+	var config *demoConfig
+	if err := container.ResolveNamed("hungry", &config); !errors.Is(err, ErrBeanTypeMismatch) {
+		t.Fatalf("expecting ErrBeanTypeMismatch, got %v", err)
+	}
+	if err := container.ResolveNamed("cold", &it); !errors.Is(err, ErrBeanNotFound) {
+		t.Fatalf("expecting ErrBeanNotFound, got %v", err)
+	}
+
+	// 没有 Primary 时存在歧义
+	container = newBeanContainer()
+	_ = container.SetBean("hungry", gHungrySingleton)
+	_ = container.ProvideNamed("contained", newContainedSingleton)
+	if err := container.Resolve(&it); !errors.Is(err, ErrAmbiguousBean) {
+		t.Fatalf("expecting ErrAmbiguousBean, got %v", err)
+	}
+}
+
+func Test_Inject(t *testing.T) {
+	container := newInjectContainer(t)
+
+	handler := demoHandler{}
+	if err := container.Inject(&handler); err != nil {
+		t.Fatal(err)
+	}
+	if handler.Default.(*greeter).name != "primary" || handler.Hungry != gHungrySingleton ||
+		handler.Config != nil || handler.Missing != nil {
+		t.Fatalf("expecting fields to be injected, got %+v", handler)
+	}
+
+	// 构造函数的结构体参数按字段注入
+	type deps struct {
+		Hello  IDemoSingleton `inject:"contained"`
+		Config *demoConfig    `inject:",optional"`
+	}
+	if err := container.Provide(func(d deps) *demoService { return &demoService{hello: d.Hello} }); err != nil {
+		t.Fatal(err)
+	}
+	var service *demoService
+	if err := container.Resolve(&service); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := service.hello.(*containedSingleton); !ok {
+		t.Fatalf("expecting qualified dependency, got %T", service.hello)
+	}
+
+	// 必需的依赖不存在时报错
+	required := struct {
+		Repo *demoRepository `inject:""`
+	}{}
+	if err := container.Inject(&required); !errors.Is(err, ErrBeanNotFound) {
+		t.Fatalf("expecting ErrBeanNotFound, got %v", err)
+	}
+	invalid := struct {
+		repo *demoRepository `inject:""`
+	}{}
+	if err := container.Inject(&invalid); !errors.Is(err, ErrInvalidInjectTag) {
+		t.Fatalf("expecting ErrInvalidInjectTag for unexported field, got %v", err)
+	}
+	badOption := struct {
+		Repo *demoRepository `inject:",lazy"`
+	}{}
+	if err := container.Inject(&badOption); !errors.Is(err, ErrInvalidInjectTag) {
+		t.Fatalf("expecting ErrInvalidInjectTag for unknown option, got %v", err)
+	}
+	if err := container.Inject(handler); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("expecting ErrInvalidTarget, got %v", err)
+	}
+}
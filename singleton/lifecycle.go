@@ -0,0 +1,192 @@
+package singleton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrContainerStarted = errors.New("container already started")
+
+// bean可选实现的初始化接口, 容器启动时按依赖顺序调用
+type IInitializer interface {
+	Init(ctx context.Context) error
+}
+
+// bean可选实现的关闭接口, 容器停止时按启动的逆序调用
+type ICloser interface {
+	Close(ctx context.Context) error
+}
+
+// 容器的生命周期
+// Start 创建全部bean并按依赖顺序调用 Init, 任一bean启动失败时停止已启动的bean并返回汇总的错误
+// Stop 按启动的逆序调用 Close, ctx 的截止时间到达后不再等待未完成的 Close
+type IBeanLifecycle interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// 汇总多个错误, errors.Is/errors.As 对其中任一错误成立即成立
+type MultiError []error
+
+func (m MultiError) Error() string {
+	items := make([]string, len(m))
+	for i, it := range m {
+		items[i] = it.Error()
+	}
+	return strings.Join(items, "; ")
+}
+
+func (m MultiError) Is(target error) bool {
+	for _, it := range m {
+		if errors.Is(it, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m MultiError) As(target interface{}) bool {
+	for _, it := range m {
+		if errors.As(it, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// 按依赖顺序排列全部bean, 依赖位于被依赖者之前, 没有依赖关系的bean保持注册顺序
+func (b *beanContainer) sortedDefinitions() []*beanDefinition {
+	b.mu.RLock()
+	order := append([]*beanDefinition{}, b.order...)
+	b.mu.RUnlock()
+
+	sorted := make([]*beanDefinition, 0, len(order))
+	visited := make(map[*beanDefinition]bool)
+	var visit func(def *beanDefinition)
+	visit = func(def *beanDefinition) {
+		if visited[def] {
+			return
+		}
+		visited[def] = true
+		for _, it := range def.deps {
+			if err, dep := b.lookup(it); err == nil {
+				visit(dep)
+			}
+		}
+		sorted = append(sorted, def)
+	}
+	for _, it := range order {
+		visit(it)
+	}
+	return sorted
+}
+
+func (b *beanContainer) Start(ctx context.Context) error {
+	b.lifecycleMu.Lock()
+	defer b.lifecycleMu.Unlock()
+
+	b.mu.Lock()
+	if b.started {
+		b.mu.Unlock()
+		return ErrContainerStarted
+	}
+	b.started = true
+	b.mu.Unlock()
+
+	errs := make(MultiError, 0)
+	failed := make(map[*beanDefinition]bool)
+	for _, def := range b.sortedDefinitions() {
+		if def.scope != ScopeSingleton {
+			continue
+		}
+		// 依赖启动失败的bean不再启动, 错误已由其依赖报告
+		if b.dependsOnFailed(def, failed) {
+			failed[def] = true
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("start %s: %w", def.name, err))
+			break
+		}
+
+		err, it := b.build(def)
+		if err == nil {
+			if initializer, ok := it.Interface().(IInitializer); ok {
+				err = initializer.Init(ctx)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("start %s: %w", def.name, err))
+			failed[def] = true
+			continue
+		}
+		b.running = append(b.running, def)
+	}
+
+	if len(errs) > 0 {
+		if err := b.stop(ctx); err != nil {
+			errs = append(errs, err.(MultiError)...)
+		}
+		return errs
+	}
+	return nil
+}
+
+func (b *beanContainer) dependsOnFailed(def *beanDefinition, failed map[*beanDefinition]bool) bool {
+	for _, it := range def.deps {
+		if err, dep := b.lookup(it); err == nil && failed[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *beanContainer) Stop(ctx context.Context) error {
+	b.lifecycleMu.Lock()
+	defer b.lifecycleMu.Unlock()
+
+	return b.stop(ctx)
+}
+
+// 按启动的逆序关闭bean, 返回的错误为 MultiError
+func (b *beanContainer) stop(ctx context.Context) error {
+	errs := make(MultiError, 0)
+	for i := len(b.running) - 1; i >= 0; i-- {
+		def := b.running[i]
+		if closer, ok := def.instance.Interface().(ICloser); ok {
+			if err := closeWithDeadline(ctx, closer); err != nil {
+				errs = append(errs, fmt.Errorf("stop %s: %w", def.name, err))
+			}
+		}
+	}
+	b.running = b.running[:0]
+
+	b.mu.Lock()
+	b.started = false
+	b.mu.Unlock()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// 截止时间到达时不再等待 Close 返回, Close 所在的协程仍会继续运行直到结束
+func closeWithDeadline(ctx context.Context, closer ICloser) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- closer.Close(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
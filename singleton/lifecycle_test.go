@@ -0,0 +1,124 @@
+package singleton
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 记录 Init/Close 调用顺序的bean
+type lifecycleRecorder struct {
+	events []string
+}
+
+type lifecycleBean struct {
+	name     string
+	recorder *lifecycleRecorder
+	initErr  error
+	closeErr error
+	delay    time.Duration
+}
+
+func (l *lifecycleBean) Init(ctx context.Context) error {
+	l.recorder.events = append(l.recorder.events, "init "+l.name)
+	return l.initErr
+}
+
+func (l *lifecycleBean) Close(ctx context.Context) error {
+	if l.delay > 0 {
+		select {
+		case <-time.After(l.delay):
+		case <-ctx.Done():
+		}
+	}
+	l.recorder.events = append(l.recorder.events, "close "+l.name)
+	return l.closeErr
+}
+
+type lifecyclePool struct{ *lifecycleBean }
+type lifecycleRepo struct{ *lifecycleBean }
+type lifecycleServer struct{ *lifecycleBean }
+
+func newLifecycleContainer(t *testing.T, recorder *lifecycleRecorder, pool *lifecycleBean) *beanContainer {
+	container := newBeanContainer()
+	// 注册顺序与依赖顺序相反
+	mustProvide(t, container, func(repo *lifecycleRepo) *lifecycleServer {
+		return &lifecycleServer{&lifecycleBean{name: "server", recorder: recorder}}
+	}, BeanOptions{})
+	mustProvide(t, container, func(pool *lifecyclePool) *lifecycleRepo {
+		return &lifecycleRepo{&lifecycleBean{name: "repo", recorder: recorder}}
+	}, BeanOptions{})
+	mustProvide(t, container, func() *lifecyclePool {
+		return &lifecyclePool{pool}
+	}, BeanOptions{})
+	return container
+}
+
+func Test_Lifecycle(t *testing.T) {
+	recorder := &lifecycleRecorder{}
+	container := newLifecycleContainer(t, recorder, &lifecycleBean{name: "pool", recorder: recorder})
+
+	if err := container.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.Start(context.Background()); !errors.Is(err, ErrContainerStarted) {
+		t.Fatalf("expecting ErrContainerStarted, got %v", err)
+	}
+	if err := container.Provide(func() *demoConfig { return nil }); !errors.Is(err, ErrContainerStarted) {
+		t.Fatalf("expecting registration to be rejected after start, got %v", err)
+	}
+	if err := container.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"init pool", "init repo", "init server", "close server", "close repo", "close pool"}
+	if !reflect.DeepEqual(recorder.events, expected) {
+		t.Fatalf("expecting %v, got %v", expected, recorder.events)
+	}
+}
+
+func Test_LifecycleError(t *testing.T) {
+	// 启动失败时依赖它的bean不启动, 已启动的bean被关闭
+	fail := errors.New("dial timeout")
+	recorder := &lifecycleRecorder{}
+	container := newLifecycleContainer(t, recorder, &lifecycleBean{name: "pool", recorder: recorder, initErr: fail, closeErr: errors.New("unused")})
+	other := &lifecycleBean{name: "cache", recorder: recorder}
+	if err := container.SetBean("cache", other); err != nil {
+		t.Fatal(err)
+	}
+
+	err := container.Start(context.Background())
+	if !errors.Is(err, fail) {
+		t.Fatalf("expecting init error, got %v", err)
+	}
+	expected := []string{"init pool", "init cache", "close cache"}
+	if !reflect.DeepEqual(recorder.events, expected) {
+		t.Fatalf("expecting %v, got %v", expected, recorder.events)
+	}
+
+	// 关闭超时与关闭失败的错误被汇总, 超时后仍继续关闭其余bean
+	recorder.events = nil
+	closeErr := errors.New("flush failed")
+	container = newLifecycleContainer(t, recorder, &lifecycleBean{name: "pool", recorder: recorder, closeErr: closeErr})
+	if err := container.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var server *lifecycleServer
+	if err := container.Resolve(&server); err != nil {
+		t.Fatal(err)
+	}
+	server.delay = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = container.Stop(ctx)
+	var errs MultiError
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("expecting 3 errors, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, closeErr) {
+		t.Fatalf("expecting deadline error for the remaining beans, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package singleton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidScope  = errors.New("invalid scope")
+	ErrScopeRequired = errors.New("request-scoped bean must be resolved within a scope")
+	ErrScopeMismatch = errors.New("singleton cannot depend on a request-scoped bean")
+	ErrScopeClosed   = errors.New("scope is closed")
+)
+
+// bean的作用域
+type Scope int
+
+const (
+	ScopeSingleton Scope = iota // 容器内只创建一次, 由容器的 Start/Stop 管理生命周期
+	ScopePrototype              // 每次获取时重新创建, 容器不管理其生命周期
+	ScopeRequest                // 每个子作用域内只创建一次, 创建时调用 Init, 作用域结束时调用 Close
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeSingleton:
+		return "singleton"
+	case ScopePrototype:
+		return "prototype"
+	case ScopeRequest:
+		return "request"
+	}
+	return fmt.Sprintf("Scope(%d)", int(s))
+}
+
+// 绑定到一次请求或一个 context.Context 的子作用域
+// 单例bean仍从容器中获取, ScopeRequest bean在子作用域内共享
+type IBeanScope interface {
+	GetBean(name string) (bool, interface{})
+	Resolve(target interface{}) error
+	ResolveNamed(name string, target interface{}) error
+	Inject(target interface{}) error
+	// 按创建的逆序关闭作用域内的bean, 重复调用时返回 nil
+	Close(ctx context.Context) error
+}
+
+// ctx 结束时自动关闭作用域, 关闭bean的最长等待时间
+const scopeCloseTimeout = 5 * time.Second
+
+type scopedBean struct {
+	mu       sync.Mutex
+	built    bool
+	instance reflect.Value
+}
+
+type beanScope struct {
+	ctx       context.Context
+	container *beanContainer
+	beans     map[*beanDefinition]*scopedBean
+	created   []*beanDefinition // 按创建顺序排列
+	closed    bool
+	done      chan struct{} // Close 时关闭, 结束等待 ctx 的协程
+	mu        sync.Mutex
+}
+
+// 创建子作用域, ctx 结束时作用域随之关闭
+func (b *beanContainer) NewScope(ctx context.Context) IBeanScope {
+	scope := &beanScope{
+		ctx:       ctx,
+		container: b,
+		beans:     make(map[*beanDefinition]*scopedBean),
+		created:   make([]*beanDefinition, 0),
+		done:      make(chan struct{}),
+	}
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-scope.done:
+				return
+			}
+			closeCtx, cancel := context.WithTimeout(context.Background(), scopeCloseTimeout)
+			defer cancel()
+			_ = scope.Close(closeCtx)
+		}()
+	}
+	return scope
+}
+
+func (s *beanScope) GetBean(name string) (bool, interface{}) {
+	return s.container.getBean(name, s)
+}
+
+func (s *beanScope) Resolve(target interface{}) error {
+	return s.container.resolve(target, s)
+}
+
+func (s *beanScope) ResolveNamed(name string, target interface{}) error {
+	return s.container.resolveNamed(name, target, s)
+}
+
+func (s *beanScope) Inject(target interface{}) error {
+	return s.container.inject(target, s)
+}
+
+func (s *beanScope) get(def *beanDefinition) (error, reflect.Value) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrScopeClosed, def.name), reflect.Value{}
+	}
+	bean, ok := s.beans[def]
+	if !ok {
+		bean = &scopedBean{}
+		s.beans[def] = bean
+	}
+	s.mu.Unlock()
+
+	bean.mu.Lock()
+	defer bean.mu.Unlock()
+	if bean.built {
+		return nil, bean.instance
+	}
+
+	err, instance := s.container.construct(def, s)
+	if err != nil {
+		return err, reflect.Value{}
+	}
+	if initializer, ok := instance.Interface().(IInitializer); ok {
+		if err := initializer.Init(s.ctx); err != nil {
+			return fmt.Errorf("init %s: %w", def.name, err), reflect.Value{}
+		}
+	}
+
+	// 创建过程中作用域已关闭, 直接关闭新创建的bean
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		if closer, ok := instance.Interface().(ICloser); ok {
+			_ = closer.Close(s.ctx)
+		}
+		return fmt.Errorf("%w: %s", ErrScopeClosed, def.name), reflect.Value{}
+	}
+	bean.built = true
+	bean.instance = instance
+	s.created = append(s.created, def)
+	s.mu.Unlock()
+	return nil, instance
+}
+
+type scopeKey struct{}
+
+// 将作用域绑定到 ctx, 例如在 HTTP 中间件中为每个请求创建作用域
+func WithScope(ctx context.Context, scope IBeanScope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+func ScopeFrom(ctx context.Context) (bool, IBeanScope) {
+	scope, ok := ctx.Value(scopeKey{}).(IBeanScope)
+	return ok, scope
+}
+
+func (s *beanScope) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	created := s.created
+	s.mu.Unlock()
+
+	errs := make(MultiError, 0)
+	for i := len(created) - 1; i >= 0; i-- {
+		def := created[i]
+		bean := s.beans[def]
+		if closer, ok := bean.instance.Interface().(ICloser); ok {
+			if err := closeWithDeadline(ctx, closer); err != nil {
+				errs = append(errs, fmt.Errorf("stop %s: %w", def.name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
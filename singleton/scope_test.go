@@ -0,0 +1,136 @@
+package singleton
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type requestSession struct {
+	*lifecycleBean
+}
+
+type requestHandler struct {
+	session *requestSession
+	config  *demoConfig
+}
+
+func newScopeContainer(t *testing.T, recorder *lifecycleRecorder) *beanContainer {
+	container := newBeanContainer()
+	sessions := 0
+	mustProvide(t, container, func() *demoConfig { return &demoConfig{dsn: "mysql://localhost"} }, BeanOptions{})
+	mustProvide(t, container, func() *requestSession {
+		sessions++
+		name := "session" + string(rune('0'+sessions))
+		return &requestSession{&lifecycleBean{name: name, recorder: recorder}}
+	}, BeanOptions{Scope: ScopeRequest})
+	mustProvide(t, container, func(session *requestSession, config *demoConfig) *requestHandler {
+		return &requestHandler{session: session, config: config}
+	}, BeanOptions{Scope: ScopePrototype})
+	return container
+}
+
+func Test_Scope(t *testing.T) {
+	recorder := &lifecycleRecorder{}
+	container := newScopeContainer(t, recorder)
+
+	scope := container.NewScope(context.Background())
+	var first, second *requestHandler
+	if err := scope.Resolve(&first); err != nil {
+		t.Fatal(err)
+	}
+	if err := scope.Resolve(&second); err != nil {
+		t.Fatal(err)
+	}
+	// 原型bean每次重新创建, 作用域内共享请求bean, 单例在容器内共享
+	if first == second || first.session != second.session {
+		t.Fatal("expecting new handlers sharing the scope's session")
+	}
+	var config *demoConfig
+	if err := container.Resolve(&config); err != nil || first.config != config {
+		t.Fatalf("expecting singleton config shared with the container, got %v", err)
+	}
+
+	other := container.NewScope(context.Background())
+	if ok, it := other.GetBean("*singleton.requestHandler"); !ok || it.(*requestHandler).session == first.session {
+		t.Fatal("expecting another scope to build its own session")
+	}
+
+	if err := scope.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := scope.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := scope.Resolve(&first); !errors.Is(err, ErrScopeClosed) {
+		t.Fatalf("expecting ErrScopeClosed, got %v", err)
+	}
+	expected := []string{"init session1", "init session2", "close session1"}
+	if !reflect.DeepEqual(recorder.events, expected) {
+		t.Fatalf("expecting %v, got %v", expected, recorder.events)
+	}
+}
+
+func Test_ScopeContext(t *testing.T) {
+	recorder := &lifecycleRecorder{}
+	container := newScopeContainer(t, recorder)
+
+	// 请求bean只能在作用域内获取
+	var session *requestSession
+	if err := container.Resolve(&session); !errors.Is(err, ErrScopeRequired) {
+		t.Fatalf("expecting ErrScopeRequired, got %v", err)
+	}
+	if err := container.Provide(func(session *requestSession) *demoRepository { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	var repo *demoRepository
+	if err := container.NewScope(context.Background()).Resolve(&repo); !errors.Is(err, ErrScopeMismatch) {
+		t.Fatalf("expecting ErrScopeMismatch, got %v", err)
+	}
+	if err := container.ProvideWith(func() int { return 1 }, BeanOptions{Scope: Scope(9)}); !errors.Is(err, ErrInvalidScope) {
+		t.Fatalf("expecting ErrInvalidScope, got %v", err)
+	}
+
+	// ctx 结束时作用域自动关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = WithScope(ctx, container.NewScope(ctx))
+	ok, scope := ScopeFrom(ctx)
+	if !ok {
+		t.Fatal("expecting scope bound to context")
+	}
+	if err := scope.Resolve(&session); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if err := scope.Resolve(&session); errors.Is(err, ErrScopeClosed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expecting scope to be closed when context is done")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 显式关闭的作用域不再等待 ctx, 长期存在的 ctx 上创建作用域不会泄漏协程
+	longLived, stop := context.WithCancel(context.Background())
+	defer stop()
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		if err := container.NewScope(longLived).Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline = time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expecting scope goroutines to exit, got %d running, %d before", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -2,6 +2,7 @@ package singleton
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 )
 
@@ -58,39 +59,44 @@ type IBeanController interface {
 }
 
 type beanContainer struct {
-	beans map[string]interface{}
-	mu    sync.RWMutex
+	definitions map[string]*beanDefinition
+	order       []*beanDefinition      // 注册顺序
+	factories   map[string]interface{} // 配置文件中 type 对应的构造函数
+	started     bool
+	mu          sync.RWMutex
+
+	running     []*beanDefinition // 已启动的bean, 按启动顺序排列
+	lifecycleMu sync.Mutex        // 串行化 Start/Stop
 }
 
 func newBeanContainer() *beanContainer {
 	return &beanContainer{
-		beans: make(map[string]interface{}),
+		definitions: make(map[string]*beanDefinition),
+		order:       make([]*beanDefinition, 0),
+		factories:   make(map[string]interface{}),
+		running:     make([]*beanDefinition, 0),
 	}
 }
 
+// 按名称获取bean, 通过构造函数注册的bean在首次获取时创建
 func (b *beanContainer) GetBean(name string) (bool, interface{}) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-
-	it, ok := b.beans[name]
-	if ok {
-		return true, it
-	}
-	return false, nil
+	return b.getBean(name, nil)
 }
 
+// 注册已创建的bean, 名称重复时返回 ErrDuplicateBean; 同一类型可以注册多个bean, 按类型获取时由 Primary 或名称区分
 func (b *beanContainer) SetBean(name string, it interface{}) error {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-
-	if _, ok := b.beans[name]; ok {
-		return nil
+	if it == nil {
+		return fmt.Errorf("%w: %s", ErrNilBean, name)
 	}
-	b.beans[name] = it
-	return nil
+	return b.register(&beanDefinition{
+		name:     name,
+		typ:      reflect.TypeOf(it),
+		built:    true,
+		instance: reflect.ValueOf(it),
+	})
 }
 
-type containedSingleton struct{
+type containedSingleton struct {
 }
 
 func (c *containedSingleton) Hello() {
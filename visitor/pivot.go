@@ -0,0 +1,266 @@
+package visitor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrNoDimension        = errors.New("pivot requires at least one dimension")
+	ErrDuplicateDimension = errors.New("duplicate pivot dimension")
+	ErrUnknownDimension   = errors.New("unknown pivot dimension")
+	ErrUnknownAggregate   = errors.New("unknown pivot aggregate")
+)
+
+// 透视表的分组维度, 即销售订单中可分组的字段
+type PivotDimension int
+
+const (
+	DimensionCity PivotDimension = iota
+	DimensionProduct
+	DimensionCustomer
+)
+
+func (d PivotDimension) String() string {
+	switch d {
+	case DimensionCity:
+		return "city"
+	case DimensionProduct:
+		return "product"
+	case DimensionCustomer:
+		return "customer"
+	}
+	return fmt.Sprintf("PivotDimension(%d)", int(d))
+}
+
+func (d PivotDimension) value(it *SaleOrder) string {
+	switch d {
+	case DimensionCity:
+		return it.City
+	case DimensionProduct:
+		return it.Product
+	}
+	return it.Customer
+}
+
+// 透视表对销售数量的统计方式
+type PivotAggregate int
+
+const (
+	AggregateSum PivotAggregate = iota
+	AggregateCount
+	AggregateAvg
+	AggregateMin
+	AggregateMax
+)
+
+func (a PivotAggregate) String() string {
+	switch a {
+	case AggregateSum:
+		return "sum"
+	case AggregateCount:
+		return "count"
+	case AggregateAvg:
+		return "avg"
+	case AggregateMin:
+		return "min"
+	case AggregateMax:
+		return "max"
+	}
+	return fmt.Sprintf("PivotAggregate(%d)", int(a))
+}
+
+// 一组订单的销售数量统计
+type PivotStats struct {
+	Count int
+	Sum   int
+	Min   int
+	Max   int
+}
+
+func (s *PivotStats) add(quantity int) {
+	if s.Count == 0 || quantity < s.Min {
+		s.Min = quantity
+	}
+	if s.Count == 0 || quantity > s.Max {
+		s.Max = quantity
+	}
+	s.Count++
+	s.Sum += quantity
+}
+
+func (s PivotStats) Avg() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Sum) / float64(s.Count)
+}
+
+func (s PivotStats) Value(aggregate PivotAggregate) float64 {
+	switch aggregate {
+	case AggregateCount:
+		return float64(s.Count)
+	case AggregateAvg:
+		return s.Avg()
+	case AggregateMin:
+		return float64(s.Min)
+	case AggregateMax:
+		return float64(s.Max)
+	}
+	return float64(s.Sum)
+}
+
+// 透视表中的一行, Keys 依次为各维度的取值
+// Keys 少于维度数的行为小计, 只包含前 len(Keys) 个维度; Keys 为空的行为总计
+type PivotRow struct {
+	Keys  []string
+	Stats PivotStats
+}
+
+// 按维度逐级排列的透视表, 每组明细之后是该组的小计, 最后一行为总计
+type PivotTable struct {
+	Dimensions []PivotDimension
+	Aggregates []PivotAggregate
+	Rows       []PivotRow
+}
+
+func (t *PivotTable) IsSubtotal(row PivotRow) bool {
+	return len(row.Keys) > 0 && len(row.Keys) < len(t.Dimensions)
+}
+
+func (t *PivotTable) IsTotal(row PivotRow) bool {
+	return len(row.Keys) == 0
+}
+
+// 透视表访问者, 可按任意维度组合分组汇总销售订单
+type IPivotVisitor interface {
+	ISaleOrderVisitor
+	Table() *PivotTable
+}
+
+// 按维度逐级分组的统计树
+type pivotNode struct {
+	stats    PivotStats
+	children map[string]*pivotNode
+}
+
+func newPivotNode() *pivotNode {
+	return &pivotNode{
+		children: make(map[string]*pivotNode, 0),
+	}
+}
+
+type pivotVisitor struct {
+	dimensions []PivotDimension
+	aggregates []PivotAggregate
+	root       *pivotNode
+}
+
+// 创建透视表访问者, 例如按城市×产品统计销售数量的合计与均值:
+//
+//	err, pv := newPivotVisitor([]PivotDimension{DimensionCity, DimensionProduct}, AggregateSum, AggregateAvg)
+//
+// 未指定统计方式时只统计合计
+func newPivotVisitor(dimensions []PivotDimension, aggregates ...PivotAggregate) (error, IPivotVisitor) {
+	if len(dimensions) == 0 {
+		return ErrNoDimension, nil
+	}
+	seen := make(map[PivotDimension]bool, 0)
+	for _, it := range dimensions {
+		if it < DimensionCity || it > DimensionCustomer {
+			return fmt.Errorf("%w: %v", ErrUnknownDimension, it), nil
+		}
+		if seen[it] {
+			return fmt.Errorf("%w: %v", ErrDuplicateDimension, it), nil
+		}
+		seen[it] = true
+	}
+	for _, it := range aggregates {
+		if it < AggregateSum || it > AggregateMax {
+			return fmt.Errorf("%w: %v", ErrUnknownAggregate, it), nil
+		}
+	}
+	if len(aggregates) == 0 {
+		aggregates = []PivotAggregate{AggregateSum}
+	}
+
+	return nil, &pivotVisitor{
+		dimensions: append([]PivotDimension{}, dimensions...),
+		aggregates: append([]PivotAggregate{}, aggregates...),
+		root:       newPivotNode(),
+	}
+}
+
+func (p *pivotVisitor) Visit(it *SaleOrder) {
+	node := p.root
+	node.stats.add(it.Quantity)
+	for _, d := range p.dimensions {
+		key := d.value(it)
+		child, ok := node.children[key]
+		if !ok {
+			child = newPivotNode()
+			node.children[key] = child
+		}
+		child.stats.add(it.Quantity)
+		node = child
+	}
+}
+
+func (p *pivotVisitor) Table() *PivotTable {
+	table := &PivotTable{
+		Dimensions: append([]PivotDimension{}, p.dimensions...),
+		Aggregates: append([]PivotAggregate{}, p.aggregates...),
+		Rows:       make([]PivotRow, 0),
+	}
+	p.appendRows(table, p.root, []string{})
+	return table
+}
+
+// 按键的顺序深度优先展开统计树, 每个节点的明细位于其小计之前
+func (p *pivotVisitor) appendRows(table *PivotTable, node *pivotNode, keys []string) {
+	names := make([]string, 0, len(node.children))
+	for k := range node.children {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		p.appendRows(table, node.children[k], append(append([]string{}, keys...), k))
+	}
+
+	// 没有任何订单时不输出总计
+	if len(keys) == 0 && node.stats.Count == 0 {
+		return
+	}
+	table.Rows = append(table.Rows, PivotRow{Keys: keys, Stats: node.stats})
+}
+
+// 按透视表的层级顺序输出报表, 小计与总计行由 Level 标记, 其中未分组的维度显示为 "*"
+func (p *pivotVisitor) Report() *Report {
+	table := p.Table()
+	keyColumns := make([]string, len(table.Dimensions))
+	for i, it := range table.Dimensions {
+		keyColumns[i] = it.String()
+	}
+	valueColumns := make([]string, len(table.Aggregates))
+	for i, it := range table.Aggregates {
+		valueColumns[i] = it.String()
+	}
+
+	report := newReport(keyColumns, valueColumns)
+	for _, row := range table.Rows {
+		keys := make([]string, len(keyColumns))
+		for i := range keys {
+			keys[i] = reportAllKey
+			if i < len(row.Keys) {
+				keys[i] = row.Keys[i]
+			}
+		}
+		values := make([]float64, len(valueColumns))
+		for i, it := range table.Aggregates {
+			values[i] = row.Stats.Value(it)
+		}
+		report.Rows = append(report.Rows, ReportRow{Keys: keys, Values: values, Level: len(keys) - len(row.Keys)})
+	}
+	return report
+}
@@ -0,0 +1,90 @@
+package visitor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newPivotSaleOrderService() ISaleOrderService {
+	service := newMockSaleOrderService()
+	_ = service.Save(newSaleOrder(1, "张三", "广州", "电视", 10))
+	_ = service.Save(newSaleOrder(2, "李四", "深圳", "冰箱", 20))
+	_ = service.Save(newSaleOrder(3, "王五", "东莞", "空调", 30))
+	_ = service.Save(newSaleOrder(4, "张三", "广州", "空调", 10))
+	_ = service.Save(newSaleOrder(5, "李四", "深圳", "电视", 20))
+	_ = service.Save(newSaleOrder(6, "张三", "广州", "电视", 40))
+	return service
+}
+
+func Test_PivotVisitor(t *testing.T) {
+	err, pv := newPivotVisitor(
+		[]PivotDimension{DimensionCity, DimensionProduct},
+		AggregateSum, AggregateCount, AggregateAvg, AggregateMin, AggregateMax,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPivotSaleOrderService().Visit(pv)
+
+	table := pv.Table()
+	expected := []PivotRow{
+		{Keys: []string{"东莞", "空调"}, Stats: PivotStats{Count: 1, Sum: 30, Min: 30, Max: 30}},
+		{Keys: []string{"东莞"}, Stats: PivotStats{Count: 1, Sum: 30, Min: 30, Max: 30}},
+		{Keys: []string{"广州", "电视"}, Stats: PivotStats{Count: 2, Sum: 50, Min: 10, Max: 40}},
+		{Keys: []string{"广州", "空调"}, Stats: PivotStats{Count: 1, Sum: 10, Min: 10, Max: 10}},
+		{Keys: []string{"广州"}, Stats: PivotStats{Count: 3, Sum: 60, Min: 10, Max: 40}},
+		{Keys: []string{"深圳", "冰箱"}, Stats: PivotStats{Count: 1, Sum: 20, Min: 20, Max: 20}},
+		{Keys: []string{"深圳", "电视"}, Stats: PivotStats{Count: 1, Sum: 20, Min: 20, Max: 20}},
+		{Keys: []string{"深圳"}, Stats: PivotStats{Count: 2, Sum: 40, Min: 20, Max: 20}},
+		{Keys: []string{}, Stats: PivotStats{Count: 6, Sum: 130, Min: 10, Max: 40}},
+	}
+	if !reflect.DeepEqual(table.Rows, expected) {
+		t.Fatalf("expecting %v, got %v", expected, table.Rows)
+	}
+
+	if !table.IsSubtotal(table.Rows[1]) || table.IsSubtotal(table.Rows[0]) || !table.IsTotal(table.Rows[8]) {
+		t.Fatal("unexpected subtotal or total row")
+	}
+	if avg := table.Rows[4].Stats.Value(AggregateAvg); avg != 20 {
+		t.Fatalf("expecting avg 20, got %v", avg)
+	}
+}
+
+func Test_PivotVisitorDimensions(t *testing.T) {
+	// 维度的顺序决定分组的层级
+	err, pv := newPivotVisitor([]PivotDimension{DimensionCustomer, DimensionCity, DimensionProduct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPivotSaleOrderService().Visit(pv)
+	table := pv.Table()
+	if table.Rows[0].Keys[0] != "张三" || len(table.Aggregates) != 1 || table.Aggregates[0] != AggregateSum {
+		t.Fatalf("unexpected table %v", table)
+	}
+	if n := len(table.Rows); n != 12 {
+		t.Fatalf("expecting 12 rows, got %d", n)
+	}
+
+	// 没有订单时表格为空
+	_, pv = newPivotVisitor([]PivotDimension{DimensionCity})
+	if rows := pv.Table().Rows; len(rows) != 0 {
+		t.Fatalf("expecting empty table, got %v", rows)
+	}
+
+	cases := []struct {
+		dimensions []PivotDimension
+		aggregates []PivotAggregate
+		err        error
+	}{
+		{nil, nil, ErrNoDimension},
+		{[]PivotDimension{DimensionCity, DimensionCity}, nil, ErrDuplicateDimension},
+		{[]PivotDimension{PivotDimension(9)}, nil, ErrUnknownDimension},
+		{[]PivotDimension{DimensionCity}, []PivotAggregate{PivotAggregate(9)}, ErrUnknownAggregate},
+	}
+	for _, it := range cases {
+		if err, _ := newPivotVisitor(it.dimensions, it.aggregates...); !errors.Is(err, it.err) {
+			t.Fatalf("expecting %v, got %v", it.err, err)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package visitor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown report format")
+	ErrUnknownColumn = errors.New("unknown report column")
+	ErrInvalidRow    = errors.New("report row does not match columns")
+)
+
+// 报表的输出格式
+type ReportFormat string
+
+const (
+	FormatCSV      ReportFormat = "csv"
+	FormatJSON     ReportFormat = "json"
+	FormatMarkdown ReportFormat = "markdown"
+	FormatText     ReportFormat = "text"
+)
+
+// 透视报表中小计与总计行未分组的维度的显示值, 判断行的类型应使用 ReportRow.Level
+const reportAllKey = "*"
+
+// 报表中的一行, Keys 与 Values 分别对应报表的维度列与统计列
+// Level 为汇总的层级, 明细行为 0, 小计与总计行为最后 Level 个维度被汇总, 这些维度的取值仅用于显示
+type ReportRow struct {
+	Keys   []string
+	Values []float64
+	Level  int
+}
+
+func (r ReportRow) IsSummary() bool {
+	return r.Level > 0
+}
+
+// 访问者输出的结构化报表, 由维度列与统计列组成
+type Report struct {
+	KeyColumns   []string
+	ValueColumns []string
+	Rows         []ReportRow
+}
+
+func newReport(keyColumns []string, valueColumns []string) *Report {
+	return &Report{
+		KeyColumns:   keyColumns,
+		ValueColumns: valueColumns,
+		Rows:         make([]ReportRow, 0),
+	}
+}
+
+func (r *Report) addRow(keys []string, values ...float64) {
+	r.Rows = append(r.Rows, ReportRow{Keys: keys, Values: values})
+}
+
+func (r *Report) clone() *Report {
+	it := newReport(r.KeyColumns, r.ValueColumns)
+	it.Rows = append(it.Rows, r.Rows...)
+	return it
+}
+
+// 按维度列依次排序, 返回排序后的新报表
+func (r *Report) SortByKey(descending bool) *Report {
+	it := r.clone()
+	sort.SliceStable(it.Rows, func(i, j int) bool {
+		c := compareRows(it.Rows[i], it.Rows[j])
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+	return it
+}
+
+// 按统计列排序, 值相同的行按维度列升序排列, 以保证结果稳定
+func (r *Report) SortByValue(column string, descending bool) (error, *Report) {
+	index := -1
+	for i, it := range r.ValueColumns {
+		if it == column {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownColumn, column), nil
+	}
+	if err := r.validate(); err != nil {
+		return err, nil
+	}
+
+	it := r.clone()
+	sort.SliceStable(it.Rows, func(i, j int) bool {
+		a, b := it.Rows[i].Values[index], it.Rows[j].Values[index]
+		if a != b {
+			return (a < b) != descending
+		}
+		return compareRows(it.Rows[i], it.Rows[j]) < 0
+	})
+	return nil, it
+}
+
+// 取前 n 行, 通常在排序之后使用
+func (r *Report) Top(n int) *Report {
+	it := r.clone()
+	if n < 0 {
+		n = 0
+	}
+	if n < len(it.Rows) {
+		it.Rows = it.Rows[:n]
+	}
+	return it
+}
+
+// 按维度依次比较, 被汇总的维度排在所有取值之后, 因此升序时小计位于其明细之后
+func compareRows(a ReportRow, b ReportRow) int {
+	for i := 0; i < len(a.Keys) && i < len(b.Keys); i++ {
+		summaryA, summaryB := i >= len(a.Keys)-a.Level, i >= len(b.Keys)-b.Level
+		switch {
+		case summaryA && summaryB:
+			continue
+		case summaryA:
+			return 1
+		case summaryB:
+			return -1
+		}
+		if c := strings.Compare(a.Keys[i], b.Keys[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a.Keys) - len(b.Keys)
+}
+
+// 按指定格式将报表写入 w, 行的维度值与统计值个数必须与列一致
+func (r *Report) Write(w io.Writer, format ReportFormat) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatCSV:
+		return r.writeCSV(w)
+	case FormatJSON:
+		return r.writeJSON(w)
+	case FormatMarkdown:
+		return r.writeMarkdown(w)
+	case FormatText:
+		return r.writeText(w)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// 报表的字段是导出的, 调用方构造的行可能与列不一致
+func (r *Report) validate() error {
+	for i, it := range r.Rows {
+		if len(it.Keys) != len(r.KeyColumns) || len(it.Values) != len(r.ValueColumns) || it.Level < 0 || it.Level > len(it.Keys) {
+			return fmt.Errorf("%w: row %d has %d keys, %d values and level %d, expecting %d keys and %d values",
+				ErrInvalidRow, i, len(it.Keys), len(it.Values), it.Level, len(r.KeyColumns), len(r.ValueColumns))
+		}
+	}
+	return nil
+}
+
+func (r *Report) columns() []string {
+	return append(append([]string{}, r.KeyColumns...), r.ValueColumns...)
+}
+
+// 以字符串形式排列的单元格, 统计值最多保留两位小数
+func (r *Report) cells() [][]string {
+	cells := make([][]string, len(r.Rows))
+	for i, row := range r.Rows {
+		cells[i] = make([]string, 0, len(r.KeyColumns)+len(r.ValueColumns))
+		cells[i] = append(cells[i], row.Keys...)
+		for _, v := range row.Values {
+			cells[i] = append(cells[i], formatValue(v))
+		}
+	}
+	return cells
+}
+
+func formatValue(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func (r *Report) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(r.columns()); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(r.cells()); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// 每行输出为一个对象, 字段按列的顺序排列
+func (r *Report) writeJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range r.Rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  {")
+		for j, column := range r.columns() {
+			var value interface{}
+			if j < len(row.Keys) {
+				value = row.Keys[j]
+			} else {
+				value = row.Values[j-len(row.Keys)]
+			}
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeJSONField(&buf, column, value); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	}
+	if len(r.Rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) error {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(k)
+	buf.WriteString(": ")
+	buf.Write(v)
+	return nil
+}
+
+func (r *Report) writeMarkdown(w io.Writer) error {
+	var buf bytes.Buffer
+	escape := strings.NewReplacer("|", "\\|", "\n", " ")
+	writeLine := func(cells []string) {
+		for i, it := range cells {
+			cells[i] = escape.Replace(it)
+		}
+		buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	writeLine(r.columns())
+	aligns := make([]string, 0, len(r.KeyColumns)+len(r.ValueColumns))
+	for range r.KeyColumns {
+		aligns = append(aligns, "---")
+	}
+	for range r.ValueColumns {
+		aligns = append(aligns, "---:")
+	}
+	buf.WriteString("| " + strings.Join(aligns, " | ") + " |\n")
+	for _, it := range r.cells() {
+		writeLine(it)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// 按显示宽度对齐的纯文本表格, 维度列左对齐, 统计列右对齐
+func (r *Report) writeText(w io.Writer) error {
+	columns := r.columns()
+	cells := r.cells()
+	widths := make([]int, len(columns))
+	for _, row := range append([][]string{columns}, cells...) {
+		for i, it := range row {
+			if n := displayWidth(it); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, row := range append([][]string{columns}, cells...) {
+		items := make([]string, len(row))
+		for i, it := range row {
+			padding := strings.Repeat(" ", widths[i]-displayWidth(it))
+			if i < len(r.KeyColumns) {
+				items[i] = it + padding
+			} else {
+				items[i] = padding + it
+			}
+		}
+		buf.WriteString(strings.TrimRight(strings.Join(items, "  "), " ") + "\n")
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// 终端中的显示宽度, 中日韩文字与全角符号占两列
+func displayWidth(s string) int {
+	n := 0
+	for _, c := range s {
+		switch {
+		case c >= 0x1100 && c <= 0x115F,
+			c >= 0x2E80 && c <= 0xA4CF,
+			c >= 0xAC00 && c <= 0xD7A3,
+			c >= 0xF900 && c <= 0xFAFF,
+			c >= 0xFE30 && c <= 0xFE4F,
+			c >= 0xFF00 && c <= 0xFF60,
+			c >= 0xFFE0 && c <= 0xFFE6,
+			c >= 0x20000 && c <= 0x3FFFD:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
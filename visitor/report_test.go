@@ -0,0 +1,156 @@
+package visitor
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func newCityReport() *Report {
+	cv := newCityVisitor()
+	newPivotSaleOrderService().Visit(cv)
+	return cv.Report()
+}
+
+func Test_ReportFormat(t *testing.T) {
+	report := newCityReport()
+	cases := []struct {
+		format   ReportFormat
+		expected string
+	}{
+		{FormatCSV, "city,sum\n东莞,30\n广州,60\n深圳,40\n"},
+		{FormatJSON, "[\n  {\"city\": \"东莞\", \"sum\": 30},\n  {\"city\": \"广州\", \"sum\": 60},\n  {\"city\": \"深圳\", \"sum\": 40}\n]\n"},
+		{FormatMarkdown, "| city | sum |\n| --- | ---: |\n| 东莞 | 30 |\n| 广州 | 60 |\n| 深圳 | 40 |\n"},
+		{FormatText, "city  sum\n东莞   30\n广州   60\n深圳   40\n"},
+	}
+	for _, it := range cases {
+		var buf bytes.Buffer
+		if err := report.Write(&buf, it.format); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != it.expected {
+			t.Fatalf("%s: expecting\n%s\ngot\n%s", it.format, it.expected, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := newReport([]string{"city"}, []string{"sum"}).Write(&buf, FormatJSON); err != nil || buf.String() != "[]\n" {
+		t.Fatalf("expecting empty array, got %q, %v", buf.String(), err)
+	}
+	if err := report.Write(&buf, "xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("expecting ErrUnknownFormat, got %v", err)
+	}
+
+	// 调用方构造的行与列不一致时返回错误而不是 panic
+	invalid := []*Report{
+		{KeyColumns: []string{"city"}, ValueColumns: []string{"sum"}, Rows: []ReportRow{{Keys: []string{"广州"}}}},
+		{KeyColumns: []string{"city"}, ValueColumns: []string{"sum"}, Rows: []ReportRow{{Values: []float64{1, 2}}}},
+		{KeyColumns: []string{"city"}, ValueColumns: []string{"sum"}, Rows: []ReportRow{{Keys: []string{"*"}, Values: []float64{1}, Level: 2}}},
+	}
+	for _, it := range invalid {
+		for _, format := range []ReportFormat{FormatCSV, FormatJSON, FormatMarkdown, FormatText} {
+			if err := it.Write(&buf, format); !errors.Is(err, ErrInvalidRow) {
+				t.Fatalf("%s: expecting ErrInvalidRow, got %v", format, err)
+			}
+		}
+		if err, _ := it.SortByValue("sum", false); !errors.Is(err, ErrInvalidRow) {
+			t.Fatalf("expecting ErrInvalidRow, got %v", err)
+		}
+	}
+}
+
+func Test_ReportSort(t *testing.T) {
+	report := newCityReport()
+	keys := func(r *Report) string {
+		var buf bytes.Buffer
+		for _, it := range r.Rows {
+			buf.WriteString(it.Keys[0])
+		}
+		return buf.String()
+	}
+
+	if s := keys(report.SortByKey(true)); s != "深圳广州东莞" {
+		t.Fatalf("unexpected order %s", s)
+	}
+	err, sorted := report.SortByValue("sum", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := keys(sorted.Top(2)); s != "广州深圳" {
+		t.Fatalf("unexpected top 2 %s", s)
+	}
+	// 排序与截取不修改原报表
+	if s := keys(report); s != "东莞广州深圳" {
+		t.Fatalf("unexpected original order %s", s)
+	}
+	if n := len(report.Top(-1).Rows) + len(report.Top(10).Rows); n != 3 {
+		t.Fatalf("expecting 3 rows, got %d", n)
+	}
+	if err, _ := report.SortByValue("avg", false); !errors.Is(err, ErrUnknownColumn) {
+		t.Fatalf("expecting ErrUnknownColumn, got %v", err)
+	}
+}
+
+func Test_PivotReport(t *testing.T) {
+	err, pv := newPivotVisitor([]PivotDimension{DimensionCity, DimensionProduct}, AggregateSum, AggregateAvg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPivotSaleOrderService().Visit(pv)
+
+	var buf bytes.Buffer
+	if err := pv.Report().Write(&buf, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	expected := "city  product  sum    avg\n" +
+		"东莞  空调      30     30\n" +
+		"东莞  *         30     30\n" +
+		"广州  电视      50     25\n" +
+		"广州  空调      10     10\n" +
+		"广州  *         60     20\n" +
+		"深圳  冰箱      20     20\n" +
+		"深圳  电视      20     20\n" +
+		"深圳  *         40     20\n" +
+		"*     *        130  21.67\n"
+	if buf.String() != expected {
+		t.Fatalf("expecting\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func Test_PivotReportSummaryRows(t *testing.T) {
+	// 维度的取值可以是 "*", 小计与总计行由 Level 区分
+	service := newMockSaleOrderService()
+	_ = service.Save(newSaleOrder(1, "张三", "广州", "电视", 10))
+	_ = service.Save(newSaleOrder(2, "李四", "*", "电视", 20))
+	_ = service.Save(newSaleOrder(3, "王五", "*", "*", 30))
+	err, pv := newPivotVisitor([]PivotDimension{DimensionCity, DimensionProduct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.Visit(pv)
+
+	rows := func(r *Report) string {
+		var buf bytes.Buffer
+		for _, it := range r.Rows {
+			buf.WriteString(it.Keys[0] + it.Keys[1])
+			if it.IsSummary() {
+				buf.WriteString("(" + strconv.Itoa(it.Level) + ")")
+			}
+			buf.WriteString(" ")
+		}
+		return buf.String()
+	}
+	report := pv.Report()
+	expected := "** *电视 **(1) 广州电视 广州*(1) **(2) "
+	if s := rows(report); s != expected {
+		t.Fatalf("expecting %s, got %s", expected, s)
+	}
+	// 升序排序时小计仍位于其明细之后, 总计位于最后
+	if s := rows(report.SortByKey(false)); s != expected {
+		t.Fatalf("expecting %s, got %s", expected, s)
+	}
+	if s := rows(report.SortByKey(true)); s != "**(2) 广州*(1) 广州电视 **(1) *电视 ** " {
+		t.Fatalf("unexpected descending order %s", s)
+	}
+}
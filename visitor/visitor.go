@@ -1,7 +1,5 @@
 package visitor
 
-import "fmt"
-
 // 销售订单实体
 type SaleOrder struct {
 	ID       int
@@ -27,10 +25,10 @@ type ISaleOrderService interface {
 	Visit(visitor ISaleOrderVisitor)
 }
 
-// 销售订单访问者接口
+// 销售订单访问者接口, Report 返回结构化的报表, 可排序后以不同格式输出
 type ISaleOrderVisitor interface {
 	Visit(it *SaleOrder)
-	Report()
+	Report() *Report
 }
 
 // 模拟销售订单服务
@@ -77,10 +75,12 @@ func (c *cityVisitor) Visit(it *SaleOrder) {
 	}
 }
 
-func (c *cityVisitor) Report() {
+func (c *cityVisitor) Report() *Report {
+	report := newReport([]string{"city"}, []string{"sum"})
 	for k, v := range c.cities {
-		fmt.Printf("city=%s, sum=%v\n", k, v)
+		report.addRow([]string{k}, float64(v))
 	}
+	return report.SortByKey(false)
 }
 
 // 品类销售报表, 按产品汇总销售情况, 实现ISaleOrderVisitor接口
@@ -103,8 +103,10 @@ func (p *productVisitor) Visit(it *SaleOrder) {
 	}
 }
 
-func (p *productVisitor) Report() {
+func (p *productVisitor) Report() *Report {
+	report := newReport([]string{"product"}, []string{"sum"})
 	for k, v := range p.products {
-		fmt.Printf("product=%s, sum=%v\n", k, v)
+		report.addRow([]string{k}, float64(v))
 	}
+	return report.SortByKey(false)
 }
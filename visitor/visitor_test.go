@@ -1,6 +1,9 @@
 package visitor
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
 func Test_Visitor(t *testing.T) {
 	service := mockSaleOrderService
@@ -15,10 +18,14 @@ func Test_Visitor(t *testing.T) {
 	// test CityVisitor
 	cv := newCityVisitor()
 	service.Visit(cv)
-	cv.Report()
+	if err := cv.Report().Write(os.Stdout, FormatText); err != nil {
+		t.Fatal(err)
+	}
 
 	// test ProductVisitor
 	pv := newProductVisitor()
 	service.Visit(pv)
-	pv.Report()
+	if err := pv.Report().Write(os.Stdout, FormatText); err != nil {
+		t.Fatal(err)
+	}
 }
\ No newline at end of file